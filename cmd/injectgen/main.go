@@ -0,0 +1,57 @@
+// Command injectgen generates plain Go code that wires an object graph the same
+// way inject.Graph.Populate would. See package injectgen for the details.
+//
+// Typical usage is via go generate:
+//
+//	//go:generate injectgen -root App
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/facebookgo/inject/injectgen"
+)
+
+func main() {
+	root := flag.String("root", "", "name of the root struct type")
+	fn := flag.String("func", "", "name of the generated function (default Inject<root>)")
+	out := flag.String("o", "inject_gen.go", "output file, relative to the package directory")
+	test := flag.Bool("test", false, "also generate a test comparing the wiring with Graph.Populate")
+	unexported := flag.Bool("unexported", false, "inject unexported fields")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	opts := injectgen.Options{Root: *root, Func: *fn, Unexported: *unexported}
+	if err := run(dir, opts, *out, *test); err != nil {
+		fmt.Fprintln(os.Stderr, "injectgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, opts injectgen.Options, out string, test bool) error {
+	result, err := injectgen.Generate(dir, opts)
+	if err != nil {
+		return err
+	}
+
+	out = filepath.Join(dir, out)
+	if err := ioutil.WriteFile(out, result.Code, 0644); err != nil {
+		return err
+	}
+	if test {
+		ext := filepath.Ext(out)
+		testOut := out[:len(out)-len(ext)] + "_test" + ext
+		if err := ioutil.WriteFile(testOut, result.Test, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+// Command injectvet reports invalid inject struct tags. It can be run
+// directly or using go vet:
+//
+//	go vet -vettool=$(which injectvet) ./...
+package main
+
+import (
+	"github.com/facebookgo/inject/injectvet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(injectvet.Analyzer)
+}
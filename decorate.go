@@ -0,0 +1,58 @@
+package inject
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// decoration identifies the decorated value of an Object for a type.
+type decoration struct {
+	object *Object
+	typ    reflect.Type
+}
+
+// loadDecorators checks the Decorators and indexes them by the type they
+// decorate.
+func (g *Graph) loadDecorators() error {
+	g.decorators = nil
+	for _, d := range g.Decorators {
+		fn := reflect.ValueOf(d)
+		t := fn.Type()
+		if t.Kind() != reflect.Func || t.NumIn() != 1 || t.NumOut() != 1 || t.In(0) != t.Out(0) {
+			return fmt.Errorf("expected decorator to be a func(T) T but got type %s", t)
+		}
+		if g.decorators == nil {
+			g.decorators = make(map[reflect.Type][]reflect.Value)
+		}
+		g.decorators[t.In(0)] = append(g.decorators[t.In(0)], fn)
+	}
+	return nil
+}
+
+// decorated returns the value of the Object to assign to a field of the given
+// type, after passing it through the Decorators for the type.
+func (g *Graph) decorated(o *Object, t reflect.Type) reflect.Value {
+	decorators := g.decorators[t]
+	if len(decorators) == 0 {
+		return reflect.ValueOf(o.Value)
+	}
+
+	key := decoration{object: o, typ: t}
+	if v, ok := g.decorations[key]; ok {
+		return v
+	}
+
+	v := reflect.New(t).Elem()
+	v.Set(reflect.ValueOf(o.Value))
+	for _, d := range decorators {
+		v = d.Call([]reflect.Value{v})[0]
+	}
+	if g.decorations == nil {
+		g.decorations = make(map[decoration]reflect.Value)
+	}
+	g.decorations[key] = v
+	if g.Logger != nil {
+		g.Logger.Debugf("decorated %s as %s", o, t)
+	}
+	return v
+}
@@ -0,0 +1,93 @@
+package inject_test
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+)
+
+type fakeRoundTripper struct{}
+
+func (*fakeRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+type wrappedRoundTripper struct {
+	name string
+	next http.RoundTripper
+}
+
+func (w *wrappedRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return w.next.RoundTrip(r)
+}
+
+func wrapRoundTripper(name string) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &wrappedRoundTripper{name: name, next: next}
+	}
+}
+
+type TypeWithRoundTripper struct {
+	Transport http.RoundTripper `inject:""`
+	Named     http.RoundTripper `inject:"transport"`
+}
+
+func TestDecorators(t *testing.T) {
+	g := inject.Graph{
+		Decorators: []interface{}{
+			wrapRoundTripper("retry"),
+			wrapRoundTripper("metrics"),
+		},
+	}
+	var a TypeWithRoundTripper
+	var b struct {
+		Transport http.RoundTripper `inject:""`
+		Named     http.RoundTripper `inject:"transport"`
+	}
+	unnamed := &fakeRoundTripper{}
+	named := &fakeRoundTripper{}
+	ensure.Nil(t, g.Provide(
+		&inject.Object{Value: &a},
+		&inject.Object{Value: &b},
+		&inject.Object{Value: unnamed},
+		&inject.Object{Value: named, Name: "transport"},
+	))
+	ensure.Nil(t, g.Populate())
+
+	metrics, ok := a.Transport.(*wrappedRoundTripper)
+	ensure.True(t, ok)
+	ensure.DeepEqual(t, metrics.name, "metrics")
+	retry, ok := metrics.next.(*wrappedRoundTripper)
+	ensure.True(t, ok)
+	ensure.DeepEqual(t, retry.name, "retry")
+	ensure.True(t, retry.next == unnamed)
+	ensure.True(t, a.Transport == b.Transport)
+
+	metrics, ok = a.Named.(*wrappedRoundTripper)
+	ensure.True(t, ok)
+	ensure.True(t, metrics.next.(*wrappedRoundTripper).next == named)
+	ensure.True(t, a.Named == b.Named)
+}
+
+func TestDecoratorsOnlyMatchTheirType(t *testing.T) {
+	g := inject.Graph{
+		Decorators: []interface{}{wrapRoundTripper("retry")},
+	}
+	var v struct {
+		Transport *fakeRoundTripper `inject:""`
+	}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+	ensure.NotNil(t, v.Transport)
+}
+
+func TestInvalidDecorator(t *testing.T) {
+	g := inject.Graph{
+		Decorators: []interface{}{func(http.RoundTripper) *fakeRoundTripper { return nil }},
+	}
+	ensure.Err(t, g.Populate(), regexp.MustCompile(
+		`^expected decorator to be a func\(T\) T but got type func\(http.RoundTripper\) \*inject_test.fakeRoundTripper$`))
+}
@@ -0,0 +1,141 @@
+package inject
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// A GraphDiff describes how the wiring of two populated Graphs differs.
+// Objects are identified by their String representation, and fields by their
+// path, which goes through inline structs and private dependencies.
+type GraphDiff struct {
+	Added   []string        `json:"added,omitempty"`   // Objects only in the second Graph.
+	Removed []string        `json:"removed,omitempty"` // Objects only in the first Graph.
+	Changed []*WiringChange `json:"changed,omitempty"`
+}
+
+// A WiringChange is a field wired to different objects in two Graphs. From or
+// To is empty if the field was not wired in the respective Graph.
+type WiringChange struct {
+	Object string `json:"object"`
+	Field  string `json:"field"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+// Diff returns how the wiring of the second Graph differs from the first.
+func Diff(a, b *Graph) *GraphDiff {
+	wa, wb := a.wiring(), b.wiring()
+	d := &GraphDiff{}
+	for _, object := range sortedKeys(wa) {
+		if _, ok := wb[object]; !ok {
+			d.Removed = append(d.Removed, object)
+		}
+	}
+	for _, object := range sortedKeys(wb) {
+		fieldsB := wb[object]
+		fieldsA, ok := wa[object]
+		if !ok {
+			d.Added = append(d.Added, object)
+			continue
+		}
+
+		var paths []string
+		for path := range fieldsA {
+			paths = append(paths, path)
+		}
+		for path := range fieldsB {
+			if _, ok := fieldsA[path]; !ok {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			if fieldsA[path] != fieldsB[path] {
+				d.Changed = append(d.Changed, &WiringChange{
+					Object: object,
+					Field:  path,
+					From:   fieldsA[path],
+					To:     fieldsB[path],
+				})
+			}
+		}
+	}
+	return d
+}
+
+// Empty reports if the Graphs have the same wiring.
+func (d *GraphDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String representation suitable for human consumption, one difference per
+// line.
+func (d *GraphDiff) String() string {
+	var buf bytes.Buffer
+	for _, o := range d.Removed {
+		fmt.Fprintf(&buf, "- %s\n", o)
+	}
+	for _, o := range d.Added {
+		fmt.Fprintf(&buf, "+ %s\n", o)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(&buf, "~ %s field %s: %s -> %s\n",
+			c.Object, c.Field, orNone(c.From), orNone(c.To))
+	}
+	return buf.String()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// wiring returns what each field path of each shared Object is wired to.
+func (g *Graph) wiring() map[string]map[string]string {
+	unnamed, named := g.view()
+	wiring := make(map[string]map[string]string)
+	for _, objects := range [][]*Object{unnamed, named} {
+		for _, o := range objects {
+			if o.private || o.embedded {
+				continue
+			}
+			fields := make(map[string]string)
+			g.walkWiring(o, "", fields)
+			wiring[o.String()] = fields
+		}
+	}
+	return wiring
+}
+
+// walkWiring records the fields of the Object, going into the inline and
+// private Objects it owns.
+func (g *Graph) walkWiring(o *Object, prefix string, fields map[string]string) {
+	e := g.edges(o)
+	for _, name := range sortedFieldNames(e.fields) {
+		dep := e.fields[name]
+		if dep.private {
+			fields[prefix+name] = "private " + dep.String()
+			g.walkWiring(dep, prefix+name+".", fields)
+			continue
+		}
+		fields[prefix+name] = dep.String()
+	}
+	for _, name := range sortedResolutionNames(e.resolutions) {
+		if r := e.resolutions[name]; r.Kind == ResolvedInline && r.Object != nil {
+			g.walkWiring(r.Object, prefix+name+".", fields)
+		}
+	}
+}
+
+func sortedKeys(m map[string]map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,136 @@
+package inject_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+)
+
+type differ interface {
+	Differ()
+}
+
+type TypeForDiffA struct{}
+
+func (*TypeForDiffA) Differ() {}
+
+type TypeForDiffB struct{}
+
+func (*TypeForDiffB) Differ() {}
+
+type TypeForDiffPrivate struct {
+	Differ differ `inject:""`
+}
+
+type TypeForDiff struct {
+	Differ  differ              `inject:""`
+	Named   *TypeAnswerStruct   `inject:"answer"`
+	Private *TypeForDiffPrivate `inject:"private"`
+	Inline  struct {
+		Differ differ `inject:""`
+	} `inject:"inline"`
+}
+
+func populatedForDiff(t *testing.T, objects ...*inject.Object) *inject.Graph {
+	var g inject.Graph
+	ensure.Nil(t, g.Provide(objects...))
+	ensure.Nil(t, g.Populate())
+	return &g
+}
+
+func TestDiffSame(t *testing.T) {
+	a := populatedForDiff(t,
+		&inject.Object{Value: &TypeForDiff{}},
+		&inject.Object{Value: &TypeForDiffA{}},
+		&inject.Object{Value: &TypeAnswerStruct{}, Name: "answer"},
+	)
+	b := populatedForDiff(t,
+		&inject.Object{Value: &TypeForDiff{}},
+		&inject.Object{Value: &TypeForDiffA{}},
+		&inject.Object{Value: &TypeAnswerStruct{}, Name: "answer"},
+	)
+	d := inject.Diff(a, b)
+	ensure.True(t, d.Empty(), d)
+	ensure.DeepEqual(t, d.String(), "")
+}
+
+func TestDiff(t *testing.T) {
+	a := populatedForDiff(t,
+		&inject.Object{Value: &TypeForDiff{}},
+		&inject.Object{Value: &TypeForDiffA{}},
+		&inject.Object{Value: &TypeAnswerStruct{}, Name: "answer"},
+	)
+	b := populatedForDiff(t,
+		&inject.Object{Value: &TypeForDiff{}},
+		&inject.Object{Value: &TypeForDiffB{}},
+		&inject.Object{Value: &TypeAnswerStruct{}, Name: "answer"},
+		&inject.Object{Value: &TypeAnswerStruct{}, Name: "added"},
+	)
+	d := inject.Diff(a, b)
+	ensure.DeepEqual(t, d.Removed, []string{"*inject_test.TypeForDiffA"})
+	ensure.DeepEqual(t, d.Added, []string{
+		"*inject_test.TypeAnswerStruct named added",
+		"*inject_test.TypeForDiffB",
+	})
+	var changed []string
+	for _, c := range d.Changed {
+		ensure.DeepEqual(t, c.Object, "*inject_test.TypeForDiff")
+		ensure.DeepEqual(t, c.From, "*inject_test.TypeForDiffA")
+		ensure.DeepEqual(t, c.To, "*inject_test.TypeForDiffB")
+		changed = append(changed, c.Field)
+	}
+	ensure.DeepEqual(t, changed, []string{"Differ", "Inline.Differ", "Private.Differ"})
+
+	ensure.DeepEqual(t, d.String(), ""+
+		"- *inject_test.TypeForDiffA\n"+
+		"+ *inject_test.TypeAnswerStruct named added\n"+
+		"+ *inject_test.TypeForDiffB\n"+
+		"~ *inject_test.TypeForDiff field Differ: *inject_test.TypeForDiffA -> *inject_test.TypeForDiffB\n"+
+		"~ *inject_test.TypeForDiff field Inline.Differ: *inject_test.TypeForDiffA -> *inject_test.TypeForDiffB\n"+
+		"~ *inject_test.TypeForDiff field Private.Differ: *inject_test.TypeForDiffA -> *inject_test.TypeForDiffB\n")
+
+	out, err := json.Marshal(&inject.GraphDiff{Changed: d.Changed[:1]})
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, string(out), `{"changed":[{"object":"*inject_test.TypeForDiff",`+
+		`"field":"Differ","from":"*inject_test.TypeForDiffA","to":"*inject_test.TypeForDiffB"}]}`)
+}
+
+func TestDiffUnwired(t *testing.T) {
+	var v struct {
+		Differ differ `inject:",optional"`
+	}
+	a := populatedForDiff(t, &inject.Object{Value: &v, Name: "v"})
+	b := populatedForDiff(t,
+		&inject.Object{Value: &struct {
+			Differ differ `inject:",optional"`
+		}{}, Name: "v"},
+		&inject.Object{Value: &TypeForDiffA{}},
+	)
+	d := inject.Diff(a, b)
+	ensure.DeepEqual(t, d.Changed, []*inject.WiringChange{{
+		Object: d.Changed[0].Object,
+		Field:  "Differ",
+		To:     "*inject_test.TypeForDiffA",
+	}})
+	ensure.StringContains(t, d.String(), "field Differ: (none) -> *inject_test.TypeForDiffA")
+}
+
+func TestDiffModuleScopedNames(t *testing.T) {
+	module := func(name string) *inject.Module {
+		return &inject.Module{
+			Name:    name,
+			Objects: []*inject.Object{{Value: &TypeAnswerStruct{}, Name: "store"}},
+			Exports: []string{},
+		}
+	}
+	var a, b inject.Graph
+	ensure.Nil(t, a.Install(module("x"), module("y")))
+	ensure.Nil(t, a.Populate())
+	ensure.Nil(t, b.Install(module("x")))
+	ensure.Nil(t, b.Populate())
+	ensure.DeepEqual(t, inject.Diff(&a, &b).Removed, []string{
+		"*inject_test.TypeAnswerStruct named store in module y",
+	})
+}
@@ -0,0 +1,123 @@
+package inject
+
+import "fmt"
+
+// FrozenError is returned when changing a frozen Graph.
+type FrozenError struct {
+	Op string // The refused operation, such as "provide".
+}
+
+func (e *FrozenError) Error() string {
+	return fmt.Sprintf("cannot %s a frozen graph", e.Op)
+}
+
+// snapshot is the immutable view of a frozen Graph. Objects resolved lazily
+// after freezing are not part of it, and neither are the fields they were
+// injected into.
+type snapshot struct {
+	unnamed []*Object
+	named   []*Object
+	edges   map[*Object]*edges
+}
+
+// edges are the injected fields of an Object and how they were resolved.
+type edges struct {
+	fields      map[string]*Object
+	resolutions map[string]*Resolution
+}
+
+// Freeze the Graph. Changing it afterwards fails with a *FrozenError, while
+// lookups like Objects, MustGet and Unused are served from a snapshot without
+// locking, even as lazy values are resolved.
+func (g *Graph) Freeze() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.freeze()
+}
+
+// Frozen reports if the Graph is frozen.
+func (g *Graph) Frozen() bool {
+	return g.snapshot() != nil
+}
+
+func (g *Graph) freeze() {
+	g.frozen.Store(g.capture())
+}
+
+// capture returns a snapshot of the Graph, which must be locked.
+func (g *Graph) capture() *snapshot {
+	unnamed, named := g.live()
+	s := &snapshot{
+		unnamed: append([]*Object(nil), unnamed...),
+		named:   named,
+		edges:   make(map[*Object]*edges, len(unnamed)+len(named)),
+	}
+	for _, objects := range [][]*Object{unnamed, named} {
+		for _, o := range objects {
+			e := &edges{
+				fields:      make(map[string]*Object, len(o.Fields)),
+				resolutions: make(map[string]*Resolution, len(o.resolutions)),
+			}
+			for name, dep := range o.Fields {
+				e.fields[name] = dep
+			}
+			for name, r := range o.resolutions {
+				r := *r
+				e.resolutions[name] = &r
+			}
+			s.edges[o] = e
+		}
+	}
+	return s
+}
+
+// current returns the snapshot of a frozen Graph, or captures one otherwise.
+func (g *Graph) current() *snapshot {
+	if s := g.snapshot(); s != nil {
+		return s
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.capture()
+}
+
+func (g *Graph) thaw() {
+	g.frozen.Store((*snapshot)(nil))
+}
+
+func (g *Graph) snapshot() *snapshot {
+	s, _ := g.frozen.Load().(*snapshot)
+	return s
+}
+
+func (g *Graph) checkFrozen(op string) error {
+	if g.snapshot() != nil {
+		return &FrozenError{Op: op}
+	}
+	return nil
+}
+
+// view returns the unnamed and named Objects, from the snapshot if the Graph
+// is frozen.
+func (g *Graph) view() (unnamed, named []*Object) {
+	if s := g.snapshot(); s != nil {
+		return s.unnamed, s.named
+	}
+	return g.live()
+}
+
+func (g *Graph) live() (unnamed, named []*Object) {
+	return g.unnamed, g.namedObjects()
+}
+
+// edges returns the edges of the Object, from the snapshot if the Graph is
+// frozen.
+func (g *Graph) edges(o *Object) *edges {
+	if s := g.snapshot(); s != nil {
+		if e := s.edges[o]; e != nil {
+			return e
+		}
+		return &edges{}
+	}
+	return &edges{fields: o.Fields, resolutions: o.resolutions}
+}
@@ -0,0 +1,92 @@
+package inject_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+)
+
+func TestFreeze(t *testing.T) {
+	var g inject.Graph
+	var v TypeNestedStruct
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+	ensure.False(t, g.Frozen())
+	g.Freeze()
+	ensure.True(t, g.Frozen())
+
+	err := g.Provide(&inject.Object{Value: &TypeAnswerStruct{}})
+	var frozen *inject.FrozenError
+	ensure.True(t, errors.As(err, &frozen))
+	ensure.DeepEqual(t, frozen.Op, "provide")
+	ensure.DeepEqual(t, err.Error(), "cannot provide a frozen graph")
+
+	ensure.True(t, errors.As(g.Populate(), &frozen))
+	ensure.DeepEqual(t, frozen.Op, "populate")
+	ensure.True(t, errors.As(g.Install(&inject.Module{Name: "m"}), &frozen))
+	ensure.True(t, errors.As(g.Remove(g.Objects()[0]), &frozen))
+
+	ensure.DeepEqual(t, len(g.Objects()), 2)
+	ensure.DeepEqual(t, len(g.Created()), 1)
+}
+
+func TestFreezeOnPopulate(t *testing.T) {
+	g := inject.Graph{FreezeOnPopulate: true}
+	var v struct {
+		A *TypeAnswerStruct `inject:""`
+		B *TypeAnswerStruct `inject:"missing"`
+	}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+
+	// A failed Populate leaves the Graph open for fixing it.
+	ensure.NotNil(t, g.Populate())
+	ensure.False(t, g.Frozen())
+
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &TypeAnswerStruct{}, Name: "missing"}))
+	ensure.Nil(t, g.Populate())
+	ensure.True(t, g.Frozen())
+}
+
+func TestFreezeLazyLookups(t *testing.T) {
+	g := inject.Graph{FreezeOnPopulate: true}
+	var v TypeWithLazy
+	ensure.Nil(t, g.Provide(
+		&inject.Object{Value: &v},
+		&inject.Object{Value: &TypeAnswerStruct{}, Name: "foo"},
+	))
+	ensure.Nil(t, g.Populate())
+
+	// Lazy values still resolve, while lookups don't race with them.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		search, err := v.Search()
+		ensure.Nil(t, err)
+		ensure.NotNil(t, search.A)
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			g.Objects()
+			g.Created()
+			g.Unused()
+			g.Health(context.Background())
+			ensure.True(t, inject.Diff(&g, &g).Empty())
+		}
+	}()
+	wg.Wait()
+}
+
+func TestResetThaws(t *testing.T) {
+	g := inject.Graph{FreezeOnPopulate: true}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &TypeNestedStruct{}}))
+	ensure.Nil(t, g.Populate())
+	g.Reset()
+	ensure.False(t, g.Frozen())
+	ensure.Nil(t, g.Populate())
+}
@@ -0,0 +1,67 @@
+//go:build go1.18
+// +build go1.18
+
+package inject
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// An Option configures an Object provided using the generic helpers.
+type Option func(*Object)
+
+// Complete marks the Object as complete.
+func Complete() Option {
+	return func(o *Object) {
+		o.Complete = true
+	}
+}
+
+// Root marks the Object as a Root.
+func Root() Option {
+	return func(o *Object) {
+		o.Root = true
+	}
+}
+
+// Provide an unnamed value to the Graph.
+func Provide[T any](g *Graph, value T, opts ...Option) error {
+	o := &Object{Value: value}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return g.Provide(o)
+}
+
+// ProvideNamed provides a named value to the Graph.
+func ProvideNamed[T any](g *Graph, name string, value T, opts ...Option) error {
+	o := &Object{Value: value, Name: name}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return g.Provide(o)
+}
+
+// Bind the interface Iface to the implementation Impl. Unnamed injects of Iface
+// will always be assigned the singleton of type Impl, which will be created if
+// it was not provided.
+func Bind[Iface, Impl any](g *Graph) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.bind(typeOf[Iface](), typeOf[Impl]())
+}
+
+// MustGet returns the value an unnamed inject of type T would be assigned. It
+// panics if there is no such value. It is only meaningful after Populate.
+func MustGet[T any](g *Graph) T {
+	o, err := g.get(typeOf[T]())
+	if err != nil {
+		panic(fmt.Sprintf("inject: %s", err))
+	}
+	return o.Value.(T)
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
@@ -0,0 +1,84 @@
+//go:build go1.18
+// +build go1.18
+
+package inject_test
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+)
+
+func TestGenericProvide(t *testing.T) {
+	var g inject.Graph
+	var v TypeInjectInterface
+	named := &TypeAnswerStruct{}
+	ensure.Nil(t, inject.Provide(&g, &v, inject.Root()))
+	ensure.Nil(t, inject.ProvideNamed(&g, "foo", named))
+	ensure.Nil(t, inject.Provide(&g, &TypeForExplainCreated{}, inject.Complete()))
+	ensure.Nil(t, g.Populate())
+
+	ensure.True(t, inject.MustGet[*TypeInjectInterface](&g) == &v)
+	ensure.True(t, inject.MustGet[*TypeAnswerStruct](&g) == v.A)
+	ensure.True(t, inject.MustGet[Answerable](&g) == v.A)
+	ensure.DeepEqual(t, len(g.Unused()), 2)
+}
+
+func TestGenericMustGetMissing(t *testing.T) {
+	var g inject.Graph
+	defer ensure.PanicDeepEqual(t, "inject: found no assignable value for type *inject_test.TypeAnswerStruct")
+	inject.MustGet[*TypeAnswerStruct](&g)
+}
+
+func TestGenericBind(t *testing.T) {
+	var g inject.Graph
+	var v TypeInjectTwoSatisfyInterface
+	ensure.Nil(t, inject.Provide(&g, &v))
+	ensure.Nil(t, inject.Bind[Answerable, *TypeNestedStruct](&g))
+	ensure.Nil(t, g.Populate())
+	ensure.True(t, v.Answerable == v.B)
+	ensure.True(t, inject.MustGet[Answerable](&g) == v.B)
+}
+
+func TestGenericBindCreates(t *testing.T) {
+	var g inject.Graph
+	var v TypeInjectInterfaceMissing
+	ensure.Nil(t, inject.Provide(&g, &v))
+	ensure.Nil(t, inject.Bind[Answerable, *TypeNestedStruct](&g))
+	ensure.Nil(t, g.Populate())
+	ensure.NotNil(t, v.Answerable)
+	ensure.NotNil(t, v.Answerable.(*TypeNestedStruct).A)
+}
+
+func TestGenericBindStrict(t *testing.T) {
+	g := inject.Graph{Strict: true}
+	var v TypeInjectInterfaceMissing
+	ensure.Nil(t, inject.Provide(&g, &v))
+	ensure.Nil(t, inject.Bind[Answerable, *TypeNestedStruct](&g))
+	ensure.Err(t, g.Populate(), regexp.MustCompile(
+		"^refusing to create bound type \\*inject_test.TypeNestedStruct in strict mode for field Answerable in type \\*inject_test.TypeInjectInterfaceMissing$"))
+
+	g = inject.Graph{
+		Strict:      true,
+		AllowCreate: []reflect.Type{reflect.TypeOf(&TypeNestedStruct{}), reflect.TypeOf(&TypeAnswerStruct{})},
+	}
+	v = TypeInjectInterfaceMissing{}
+	ensure.Nil(t, inject.Provide(&g, &v))
+	ensure.Nil(t, inject.Bind[Answerable, *TypeNestedStruct](&g))
+	ensure.Nil(t, g.Populate())
+	ensure.NotNil(t, v.Answerable)
+}
+
+func TestGenericBindErrors(t *testing.T) {
+	var g inject.Graph
+	ensure.Err(t, inject.Bind[*TypeAnswerStruct, *TypeAnswerStruct](&g),
+		regexp.MustCompile("^cannot bind non interface type"))
+	ensure.Err(t, inject.Bind[Answerable, *TypeForExplainCreated](&g),
+		regexp.MustCompile("^cannot bind inject_test.Answerable to \\*inject_test.TypeForExplainCreated"))
+	ensure.Nil(t, inject.Bind[Answerable, *TypeAnswerStruct](&g))
+	ensure.Err(t, inject.Bind[Answerable, *TypeNestedStruct](&g),
+		regexp.MustCompile("^inject_test.Answerable is already bound to \\*inject_test.TypeAnswerStruct$"))
+}
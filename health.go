@@ -0,0 +1,168 @@
+package inject
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// HealthChecker is implemented by Objects that can report their health.
+type HealthChecker interface {
+	Healthy(ctx context.Context) error
+}
+
+// ObjectHealth is the health of an Object implementing HealthChecker.
+type ObjectHealth struct {
+	Object *Object
+	Err    error // The error the check returned, nil if healthy.
+
+	// Causes are the unhealthy dependencies, direct or not, which are the root
+	// causes of the Object being unhealthy. It is empty if the Object is
+	// unhealthy on its own.
+	Causes []*Object
+}
+
+// A HealthReport rolls up the health of the Objects in a Graph.
+type HealthReport struct {
+	Objects []*ObjectHealth // In the order the Objects were provided.
+}
+
+// Healthy reports if all the Objects are healthy.
+func (r *HealthReport) Healthy() bool {
+	for _, h := range r.Objects {
+		if h.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Causes returns the unhealthy Objects which do not depend on other unhealthy
+// Objects.
+func (r *HealthReport) Causes() []*ObjectHealth {
+	var causes []*ObjectHealth
+	for _, h := range r.Objects {
+		if h.Err != nil && len(h.Causes) == 0 {
+			causes = append(causes, h)
+		}
+	}
+	return causes
+}
+
+// String representation suitable for human consumption. Objects unhealthy
+// because of their dependencies are listed along with their causes rather than
+// their own errors.
+func (r *HealthReport) String() string {
+	var buf bytes.Buffer
+	for _, h := range r.Objects {
+		switch {
+		case h.Err == nil:
+			fmt.Fprintf(&buf, "healthy %s\n", h.Object)
+		case len(h.Causes) == 0:
+			fmt.Fprintf(&buf, "unhealthy %s: %s\n", h.Object, h.Err)
+		default:
+			fmt.Fprintf(&buf, "unhealthy %s because of", h.Object)
+			for i, c := range h.Causes {
+				if i != 0 {
+					buf.WriteString(",")
+				}
+				fmt.Fprintf(&buf, " %s", c)
+			}
+			buf.WriteString("\n")
+		}
+	}
+	return buf.String()
+}
+
+// Health checks the Objects implementing HealthChecker concurrently. Objects
+// are only blamed on their own if none of their dependencies are unhealthy,
+// following the injected fields of the Objects.
+func (g *Graph) Health(ctx context.Context) *HealthReport {
+	// Lazy fields may be resolved while checking, so a snapshot is used.
+	s := g.current()
+	objects := append(append([]*Object(nil), s.unnamed...), s.named...)
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].seq < objects[j].seq
+	})
+
+	r := &HealthReport{}
+	health := make(map[*Object]*ObjectHealth)
+	var wg sync.WaitGroup
+	for _, o := range objects {
+		checker, ok := o.Value.(HealthChecker)
+		if !ok || o.embedded {
+			continue
+		}
+		h := &ObjectHealth{Object: o}
+		health[o] = h
+		r.Objects = append(r.Objects, h)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.Err = checker.Healthy(ctx)
+		}()
+	}
+	wg.Wait()
+
+	for _, h := range r.Objects {
+		if h.Err == nil {
+			continue
+		}
+		visited := map[*Object]bool{h.Object: true}
+		var unhealthy []*Object
+		var visit func(o *Object)
+		visit = func(o *Object) {
+			for _, dep := range s.dependencies(o) {
+				if visited[dep] {
+					continue
+				}
+				visited[dep] = true
+				if d := health[dep]; d != nil && d.Err != nil {
+					unhealthy = append(unhealthy, dep)
+				}
+				visit(dep)
+			}
+		}
+		visit(h.Object)
+		h.Causes = unhealthy
+	}
+
+	// Only keep the root causes, which do not have causes of their own.
+	root := make(map[*Object]bool)
+	for _, h := range r.Objects {
+		if h.Err != nil && len(h.Causes) == 0 {
+			root[h.Object] = true
+		}
+	}
+	for _, h := range r.Objects {
+		causes := h.Causes[:0]
+		for _, c := range h.Causes {
+			if root[c] {
+				causes = append(causes, c)
+			}
+		}
+		h.Causes = causes
+	}
+	return r
+}
+
+// dependencies returns the Objects injected into the fields of the Object,
+// along with the inline Objects it was traversed into, in field order.
+func (s *snapshot) dependencies(o *Object) []*Object {
+	e := s.edges[o]
+	if e == nil {
+		return nil
+	}
+	var deps []*Object
+	for _, name := range sortedFieldNames(e.fields) {
+		deps = append(deps, e.fields[name])
+	}
+	for _, name := range sortedResolutionNames(e.resolutions) {
+		if r := e.resolutions[name]; r.Kind == ResolvedInline && r.Object != nil {
+			deps = append(deps, r.Object)
+		}
+	}
+	return deps
+}
@@ -0,0 +1,114 @@
+package inject_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+)
+
+type TypeForHealthDB struct {
+	err error
+}
+
+func (d *TypeForHealthDB) Healthy(ctx context.Context) error {
+	return d.err
+}
+
+type TypeForHealthRepo struct {
+	DB *TypeForHealthDB `inject:""`
+}
+
+type TypeForHealthAPI struct {
+	Repo *TypeForHealthRepo `inject:""`
+	err  error
+}
+
+func (a *TypeForHealthAPI) Healthy(ctx context.Context) error {
+	if err := a.Repo.DB.Healthy(ctx); err != nil {
+		return err
+	}
+	return a.err
+}
+
+type TypeForHealthCache struct {
+	err error
+}
+
+func (c *TypeForHealthCache) Healthy(ctx context.Context) error {
+	return c.err
+}
+
+func TestHealth(t *testing.T) {
+	var g inject.Graph
+	api := &TypeForHealthAPI{}
+	db := &TypeForHealthDB{}
+	cache := &TypeForHealthCache{}
+	ensure.Nil(t, g.Provide(
+		&inject.Object{Value: api},
+		&inject.Object{Value: db},
+		&inject.Object{Value: cache},
+	))
+	ensure.Nil(t, g.Populate())
+
+	r := g.Health(context.Background())
+	ensure.True(t, r.Healthy())
+	ensure.DeepEqual(t, len(r.Objects), 3)
+	ensure.DeepEqual(t, r.String(), ""+
+		"healthy *inject_test.TypeForHealthAPI\n"+
+		"healthy *inject_test.TypeForHealthDB\n"+
+		"healthy *inject_test.TypeForHealthCache\n")
+
+	db.err = errors.New("connection refused")
+	cache.err = errors.New("evicted")
+	r = g.Health(context.Background())
+	ensure.False(t, r.Healthy())
+	ensure.DeepEqual(t, r.Objects[0].Err, db.err)
+	ensure.DeepEqual(t, r.Objects[0].Causes, []*inject.Object{r.Objects[1].Object})
+	ensure.DeepEqual(t, len(r.Causes()), 2)
+	ensure.DeepEqual(t, r.String(), ""+
+		"unhealthy *inject_test.TypeForHealthAPI because of *inject_test.TypeForHealthDB\n"+
+		"unhealthy *inject_test.TypeForHealthDB: connection refused\n"+
+		"unhealthy *inject_test.TypeForHealthCache: evicted\n")
+}
+
+func TestHealthOwnError(t *testing.T) {
+	var g inject.Graph
+	api := &TypeForHealthAPI{err: errors.New("overloaded")}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: api}))
+	ensure.Nil(t, g.Populate())
+
+	r := g.Health(context.Background())
+	causes := r.Causes()
+	ensure.DeepEqual(t, len(causes), 1)
+	ensure.True(t, causes[0].Object.Value == api)
+	ensure.DeepEqual(t, r.String(), ""+
+		"unhealthy *inject_test.TypeForHealthAPI: overloaded\n"+
+		"healthy *inject_test.TypeForHealthDB\n")
+}
+
+func TestHealthWhileResolvingLazily(t *testing.T) {
+	var g inject.Graph
+	var v struct {
+		API func() (*TypeForHealthAPI, error) `inject:",lazy"`
+	}
+	db := &TypeForHealthDB{err: errors.New("down")}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}, &inject.Object{Value: db}))
+	ensure.Nil(t, g.Populate())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := v.API()
+		ensure.Nil(t, err)
+	}()
+	for i := 0; i < 10; i++ {
+		g.Health(context.Background())
+	}
+	<-done
+	r := g.Health(context.Background())
+	ensure.DeepEqual(t, len(r.Objects), 2)
+	ensure.DeepEqual(t, r.Objects[1].Causes, []*inject.Object{r.Objects[0].Object})
+}
@@ -9,7 +9,9 @@
 // instances.
 //
 // It works using Go's reflection package and is inherently limited in what it
-// can do as opposed to a code-gen system with respect to private fields.
+// can do as opposed to a code-gen system with respect to private fields. The
+// Graph can be told to inject unexported fields anyway, in which case it relies
+// on the unsafe package to do so.
 //
 // The usage pattern for the library involves struct tags. It requires the tag
 // format used by the various standard libraries, like json, xml etc. It
@@ -23,6 +25,23 @@
 // of the associated type. The second triggers creation of a private instance
 // for the associated type. Finally the last form is asking for a named
 // dependency called "dev logger".
+//
+// Instead of a name, a field may ask for an object qualified by a marker type,
+// as in `inject:"qualifier=pkg.Primary"`. The object is provided with a value
+// of the marker type as its Qualifier, and is identified by the qualifier
+// together with its type. The qualifier is written as the marker type's name
+// qualified by either its package name or its full import path.
+//
+// Options may follow the value, separated by commas. The lazy option, as in
+// `inject:",lazy"`, requires a field of type func() (T, error) and defers
+// resolving the dependency until the function is first called. The intercept
+// option, as in `inject:",intercept=audit"`, routes method calls on an
+// interface field through the Interceptor with the given name. The optional
+// option leaves the field alone if there is nothing to inject, rather than
+// failing.
+//
+// Populate may be called again after providing more objects. It fills the new
+// objects and the optional fields still missing, and leaves the others alone.
 package inject
 
 import (
@@ -30,8 +49,13 @@ import (
 	"fmt"
 	"math/rand"
 	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
 
-	"github.com/facebookgo/structtag"
+	"github.com/facebookgo/inject/internal/injecttag"
 )
 
 // Logger allows for simple logging as inject traverses and populates the
@@ -40,6 +64,57 @@ type Logger interface {
 	Debugf(format string, v ...interface{})
 }
 
+// EventKind identifies the kind of an Event.
+type EventKind int
+
+const (
+	EventProvided          EventKind = iota + 1 // An Object was provided.
+	EventCreated                                // An Object was created.
+	EventAssigned                               // A field was assigned an Object.
+	EventInterfaceAssigned                      // An interface field was assigned an Object.
+	EventMapMade                                // A map was made for a field.
+	EventInlineProvided                         // An inline struct field was provided.
+)
+
+var eventKindNames = map[EventKind]string{
+	EventProvided:          "provided",
+	EventCreated:           "created",
+	EventAssigned:          "assigned",
+	EventInterfaceAssigned: "interface assigned",
+	EventMapMade:           "map made",
+	EventInlineProvided:    "inline provided",
+}
+
+// String representation suitable for human consumption.
+func (k EventKind) String() string {
+	if name, ok := eventKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// An Event describes a single step taken while populating the object graph.
+// Field and Dep are only set for events about a field, in which case Object
+// is the Object the field belongs to.
+type Event struct {
+	Kind   EventKind
+	Object *Object
+	Field  string
+	Dep    *Object
+}
+
+// EventLogger receives structured events as inject traverses and populates
+// the object graph.
+type EventLogger interface {
+	Event(e Event)
+}
+
+// Creatable is a marker interface for types the Graph may create on demand
+// even when it is in Strict mode.
+type Creatable interface {
+	InjectCreatable()
+}
+
 // Populate is a short-hand for populating a graph with the given incomplete
 // object values.
 func Populate(values ...interface{}) error {
@@ -56,13 +131,21 @@ func Populate(values ...interface{}) error {
 type Object struct {
 	Value        interface{}
 	Name         string             // Optional
+	Qualifier    interface{}        // Optional marker value, whose type qualifies the Object
 	Complete     bool               // If true, the Value will be considered complete
+	Root         bool               // If true, the Value is an entry point and never considered unused
 	Fields       map[string]*Object // Populated with the field names that were injected and their corresponding *Object.
 	reflectType  reflect.Type
 	reflectValue reflect.Value
+	resolutions  map[string]*Resolution
+	duration     time.Duration
 	private      bool // If true, the Value will not be used and will only be populated
 	created      bool // If true, the Object was created by us
+	populated    bool // If true, the Object was in the Graph when Populate last succeeded
+	pending      bool // If true, optional fields of the Object are still missing
+	seq          int  // The order the Object was provided in
 	embedded     bool // If true, the Object is an embedded struct provided internally
+	module       *Module
 }
 
 // String representation suitable for human consumption.
@@ -71,10 +154,34 @@ func (o *Object) String() string {
 	fmt.Fprint(&buf, o.reflectType)
 	if o.Name != "" {
 		fmt.Fprintf(&buf, " named %s", o.Name)
+		// Names private to a Module may be provided by other Modules too.
+		if key := namedKeyFor(o); key.module != nil {
+			fmt.Fprintf(&buf, " in module %s", key.module.Name)
+		}
+	}
+	if o.Qualifier != nil {
+		fmt.Fprintf(&buf, " qualified by %s", reflect.TypeOf(o.Qualifier))
 	}
 	return buf.String()
 }
 
+// Created reports if the Object was created by the Graph.
+func (o *Object) Created() bool {
+	return o.created
+}
+
+// Private reports if the Object is a private instance only used by the Object
+// it was injected into.
+func (o *Object) Private() bool {
+	return o.private
+}
+
+// Embedded reports if the Object is an inline struct the Graph provided
+// internally.
+func (o *Object) Embedded() bool {
+	return o.embedded
+}
+
 func (o *Object) addDep(field string, dep *Object) {
 	if o.Fields == nil {
 		o.Fields = make(map[string]*Object)
@@ -82,17 +189,117 @@ func (o *Object) addDep(field string, dep *Object) {
 	o.Fields[field] = dep
 }
 
+func (o *Object) addResolution(r *Resolution) {
+	if o.resolutions == nil {
+		o.resolutions = make(map[string]*Resolution)
+	}
+	o.resolutions[r.Field] = r
+}
+
+// ResolutionKind describes how a field was resolved.
+type ResolutionKind int
+
+const (
+	ResolvedNamed     ResolutionKind = iota + 1 // Assigned the object with the requested name.
+	ResolvedExisting                            // Assigned an existing singleton.
+	ResolvedCreated                             // Assigned a newly created object.
+	ResolvedInterface                           // Assigned the only object implementing the interface.
+	ResolvedInline                              // Traversed into as an inline struct.
+	ResolvedMap                                 // Assigned a newly made map.
+	ResolvedPreset                              // Left alone because it already had a value.
+	ResolvedLazy                                // Assigned a function resolving the Object on first use.
+	ResolvedQualified                           // Assigned the object with the requested qualifier.
+)
+
+var resolutionKindNames = map[ResolutionKind]string{
+	ResolvedNamed:     "named",
+	ResolvedExisting:  "existing",
+	ResolvedCreated:   "created",
+	ResolvedInterface: "interface",
+	ResolvedInline:    "inline",
+	ResolvedMap:       "map",
+	ResolvedPreset:    "preset",
+	ResolvedLazy:      "lazy",
+	ResolvedQualified: "qualified",
+}
+
+// String representation suitable for human consumption.
+func (k ResolutionKind) String() string {
+	if name, ok := resolutionKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("ResolutionKind(%d)", int(k))
+}
+
+// A Candidate that was considered and rejected while resolving a field.
+type Candidate struct {
+	Object *Object
+	Reason string
+}
+
+// A Resolution describes how a field was resolved during Populate.
+type Resolution struct {
+	Field    string
+	Kind     ResolutionKind
+	Object   *Object      // The assigned Object, nil for maps, preset and unresolved lazy values.
+	Rejected []*Candidate // Only populated for interface fields.
+}
+
 // The Graph of Objects.
 type Graph struct {
-	Logger      Logger // Optional, will trigger debug logging.
+	Logger      Logger         // Optional, will trigger debug logging.
+	EventLogger EventLogger    // Optional, will receive structured events.
+	Tracer      Tracer         // Optional, will receive spans.
+	Strict      bool           // Optional, if true only allowed types will be created.
+	AllowCreate []reflect.Type // Types that may be created in Strict mode.
+	NoUnused    bool           // Optional, if true Populate fails if provided objects are unused.
+	Unexported  bool           // Optional, if true unexported fields will be injected using unsafe.
+
+	// FreezeOnPopulate freezes the Graph once Populate succeeds.
+	FreezeOnPopulate bool
+
+	// Decorators are functions of the form func(T) T. Values injected into
+	// fields of type T are passed through them in order, so the last one
+	// wraps all the others. Each Object is decorated once per type, and it may
+	// not have been populated yet when it is decorated.
+	Decorators []interface{}
+
+	// Interceptors are called for method calls on interface values injected
+	// into the fields they select. Proxies make the values standing in for the
+	// real ones, and are functions of the form func(Handler) T for interface
+	// types T. Since proxies cannot be made using reflect, every intercepted
+	// interface type needs one.
+	Interceptors []*Interceptor
+	Proxies      []interface{}
+
 	unnamed     []*Object
 	unnamedType map[reflect.Type]bool
-	named       map[string]*Object
+	named       map[namedKey]*Object
+	duration    time.Duration
+	mu          sync.Mutex // Held while populating, including lazy values.
+	bindings    map[reflect.Type]reflect.Type
+	modules     map[string]*Module
+	decorators  map[reflect.Type][]reflect.Value
+	decorations map[decoration]reflect.Value
+	proxies     map[reflect.Type]reflect.Value
+	proxied     map[interception]reflect.Value
+	frozen      atomic.Value // Holds the *snapshot of a frozen Graph.
+	ambiguities []*Ambiguity
+	seq         int
 }
 
 // Provide objects to the Graph. The Object documentation describes
 // the impact of various fields.
 func (g *Graph) Provide(objects ...*Object) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkFrozen("provide"); err != nil {
+		return err
+	}
+	return g.provide(objects...)
+}
+
+func (g *Graph) provide(objects ...*Object) error {
 	for _, o := range objects {
 		o.reflectType = reflect.TypeOf(o.Value)
 		o.reflectValue = reflect.ValueOf(o.Value)
@@ -104,8 +311,30 @@ func (g *Graph) Provide(objects ...*Object) error {
 			)
 		}
 
-		if o.Name == "" {
-			if !isStructPtr(o.reflectType) {
+		if o.Qualifier != nil && o.Name != "" {
+			return fmt.Errorf(
+				"object %s cannot have both a name and a qualifier",
+				o,
+			)
+		}
+
+		if o.Qualifier != nil {
+			if g.named == nil {
+				g.named = make(map[namedKey]*Object)
+			}
+
+			key := namedKey{qualifier: reflect.TypeOf(o.Qualifier), typ: o.reflectType}
+			if existing := g.named[key]; existing != nil {
+				return fmt.Errorf(
+					"provided two instances of type %s qualified by %s%s",
+					o.reflectType,
+					key.qualifier,
+					g.providedBy(existing),
+				)
+			}
+			g.named[key] = o
+		} else if o.Name == "" {
+			if !isStructPtr(o.reflectType) && !isFuncOrChan(o.reflectType) {
 				return fmt.Errorf(
 					"expected unnamed object value to be a pointer to a struct but got type %s "+
 						"with value %v",
@@ -120,9 +349,17 @@ func (g *Graph) Provide(objects ...*Object) error {
 				}
 
 				if g.unnamedType[o.reflectType] {
+					if !isStructPtr(o.reflectType) {
+						return fmt.Errorf(
+							"provided two unnamed instances of type %s%s",
+							o.reflectType,
+							g.providedBy(g.unnamedOfType(o.reflectType)),
+						)
+					}
 					return fmt.Errorf(
-						"provided two unnamed instances of type *%s.%s",
+						"provided two unnamed instances of type *%s.%s%s",
 						o.reflectType.Elem().PkgPath(), o.reflectType.Elem().Name(),
+						g.providedBy(g.unnamedOfType(o.reflectType)),
 					)
 				}
 				g.unnamedType[o.reflectType] = true
@@ -130,15 +367,23 @@ func (g *Graph) Provide(objects ...*Object) error {
 			g.unnamed = append(g.unnamed, o)
 		} else {
 			if g.named == nil {
-				g.named = make(map[string]*Object)
+				g.named = make(map[namedKey]*Object)
 			}
 
-			if g.named[o.Name] != nil {
-				return fmt.Errorf("provided two instances named %s", o.Name)
+			key := namedKeyFor(o)
+			if existing := g.named[key]; existing != nil {
+				return fmt.Errorf(
+					"provided two instances named %s%s",
+					o.Name,
+					g.providedBy(existing),
+				)
 			}
-			g.named[o.Name] = o
+			g.named[key] = o
 		}
 
+		g.seq++
+		o.seq = g.seq
+
 		if g.Logger != nil {
 			if o.created {
 				g.Logger.Debugf("created %s", o)
@@ -148,25 +393,115 @@ func (g *Graph) Provide(objects ...*Object) error {
 				g.Logger.Debugf("provided %s", o)
 			}
 		}
+
+		// Inline objects are reported along with their field when populating.
+		if o.created {
+			g.emit(Event{Kind: EventCreated, Object: o})
+		} else if !o.private {
+			g.emit(Event{Kind: EventProvided, Object: o})
+		}
 	}
 	return nil
 }
 
+func (g *Graph) emit(e Event) {
+	if g.EventLogger != nil {
+		g.EventLogger.Event(e)
+	}
+}
+
 // Populate the incomplete Objects.
 func (g *Graph) Populate() error {
-	for _, o := range g.named {
-		if o.Complete {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.checkFrozen("populate"); err != nil {
+		return err
+	}
+
+	var span Span
+	if g.Tracer != nil {
+		span = g.Tracer.StartSpan("populate graph", nil)
+	}
+	start := time.Now()
+	err := g.populate()
+	g.duration += time.Since(start)
+	if span != nil {
+		span.End()
+	}
+	if err == nil && g.FreezeOnPopulate {
+		g.freeze()
+	}
+	return err
+}
+
+func (g *Graph) populate() error {
+	if err := g.loadDecorators(); err != nil {
+		return err
+	}
+	if err := g.loadInterceptors(); err != nil {
+		return err
+	}
+
+	// Objects with optional fields still missing are walked again.
+	populated := make(map[*Object]bool)
+	for _, o := range g.allObjects() {
+		if o.populated {
+			populated[o] = true
+			o.populated = !o.pending
+		}
+	}
+
+	for _, o := range g.namedObjects() {
+		if o.settled() {
 			continue
 		}
 
-		if err := g.populateExplicit(o); err != nil {
+		if err := g.timed("populate", o, g.populateExplicit); err != nil {
 			return err
 		}
 	}
 
+	if err := g.populateUnnamed(0); err != nil {
+		return err
+	}
+
+	for _, o := range g.namedObjects() {
+		if o.settled() {
+			continue
+		}
+
+		if err := g.timed("populate interfaces", o, g.populateUnnamedInterface); err != nil {
+			return err
+		}
+	}
+
+	if g.NoUnused {
+		if unused := g.Unused(); len(unused) != 0 {
+			var buf bytes.Buffer
+			for i, o := range unused {
+				if i != 0 {
+					buf.WriteString(", ")
+				}
+				fmt.Fprint(&buf, o)
+			}
+			return fmt.Errorf("provided objects were never injected: %s", &buf)
+		}
+	}
+
+	g.findAmbiguous(populated)
+	for _, o := range g.allObjects() {
+		o.populated = true
+	}
+	return nil
+}
+
+// populateUnnamed populates the unnamed objects starting at the given index,
+// including the ones that get created along the way.
+func (g *Graph) populateUnnamed(start int) error {
 	// We append and modify our slice as we go along, so we don't use a standard
 	// range loop, and do a single pass thru each object in our graph.
-	i := 0
+	i := start
 	for {
 		if i == len(g.unnamed) {
 			break
@@ -175,45 +510,35 @@ func (g *Graph) Populate() error {
 		o := g.unnamed[i]
 		i++
 
-		if o.Complete {
+		if o.settled() {
 			continue
 		}
 
-		if err := g.populateExplicit(o); err != nil {
+		if err := g.timed("populate", o, g.populateExplicit); err != nil {
 			return err
 		}
 	}
 
 	// A Second pass handles injecting Interface values to ensure we have created
 	// all concrete types first.
-	for _, o := range g.unnamed {
-		if o.Complete {
+	for _, o := range g.unnamed[start:] {
+		if o.settled() {
 			continue
 		}
 
-		if err := g.populateUnnamedInterface(o); err != nil {
+		if err := g.timed("populate interfaces", o, g.populateUnnamedInterface); err != nil {
 			return err
 		}
 	}
-
-	for _, o := range g.named {
-		if o.Complete {
-			continue
-		}
-
-		if err := g.populateUnnamedInterface(o); err != nil {
-			return err
-		}
-	}
-
 	return nil
 }
 
 func (g *Graph) populateExplicit(o *Object) error {
-	// Ignore named value types.
-	if o.Name != "" && !isStructPtr(o.reflectType) {
+	// Ignore named value types, functions and channels.
+	if !isStructPtr(o.reflectType) {
 		return nil
 	}
+	o.pending = false
 
 StructLoop:
 	for i := 0; i < o.reflectValue.Elem().NumField(); i++ {
@@ -221,7 +546,7 @@ StructLoop:
 		fieldType := field.Type()
 		fieldTag := o.reflectType.Elem().Field(i).Tag
 		fieldName := o.reflectType.Elem().Field(i).Name
-		tag, err := parseTag(string(fieldTag))
+		tag, err := injecttag.Parse(string(fieldTag))
 		if err != nil {
 			return fmt.Errorf(
 				"unexpected tag format `%s` for field %s in type %s",
@@ -236,17 +561,22 @@ StructLoop:
 			continue
 		}
 
-		// Cannot be used with unexported fields.
+		// Cannot be used with unexported fields, unless explicitly allowed.
 		if !field.CanSet() {
-			return fmt.Errorf(
-				"inject requested on unexported field %s in type %s",
-				o.reflectType.Elem().Field(i).Name,
-				o.reflectType,
-			)
+			if !g.Unexported {
+				return fmt.Errorf(
+					"inject requested on unexported field %s in type %s",
+					o.reflectType.Elem().Field(i).Name,
+					o.reflectType,
+				)
+			}
+			field = unexportedField(field)
 		}
 
-		// Inline tag on anything besides a struct is considered invalid.
-		if tag.Inline && fieldType.Kind() != reflect.Struct {
+		// Inline tag on anything besides a struct or an embedded pointer to a
+		// struct is considered invalid.
+		embeddedPtr := o.reflectType.Elem().Field(i).Anonymous && isStructPtr(fieldType)
+		if tag.Inline && fieldType.Kind() != reflect.Struct && !embeddedPtr {
 			return fmt.Errorf(
 				"inline requested on non inlined field %s in type %s",
 				o.reflectType.Elem().Field(i).Name,
@@ -255,13 +585,56 @@ StructLoop:
 		}
 
 		// Don't overwrite existing values.
+		// Fields resolved by an earlier Populate keep their Resolution.
 		if !isNilOrZero(field, fieldType) {
+			if o.resolutions[fieldName] == nil {
+				o.addResolution(&Resolution{Field: fieldName, Kind: ResolvedPreset})
+			}
 			continue
 		}
 
+		// Lazy injects get a function resolving the dependency on first use.
+		if tag.Lazy {
+			if !isLazyFunc(fieldType) {
+				return fmt.Errorf(
+					"lazy inject on field %s in type %s must be of type func() (T, error)",
+					o.reflectType.Elem().Field(i).Name,
+					o.reflectType,
+				)
+			}
+
+			r := &Resolution{Field: fieldName, Kind: ResolvedLazy}
+			field.Set(g.lazy(o, r, fieldType, tag))
+			if g.Logger != nil {
+				g.Logger.Debugf(
+					"assigned lazy function to field %s in %s",
+					o.reflectType.Elem().Field(i).Name,
+					o,
+				)
+			}
+			o.addResolution(r)
+			continue
+		}
+
+		// Intercepted injects need an interface type with a proxy.
+		if tag.Intercept != "" {
+			if err := g.checkIntercept(fieldType, tag.Intercept); err != nil {
+				return fmt.Errorf(
+					"cannot intercept field %s in type %s: %s",
+					o.reflectType.Elem().Field(i).Name,
+					o.reflectType,
+					err,
+				)
+			}
+		}
+
 		// Named injects must have been explicitly provided.
 		if tag.Name != "" {
-			existing := g.named[tag.Name]
+			existing := g.lookupNamed(o.module, tag.Name)
+			if existing == nil && tag.Optional {
+				g.skipOptional(o, fieldName)
+				continue
+			}
 			if existing == nil {
 				return fmt.Errorf(
 					"did not find object named %s required by field %s in type %s",
@@ -282,7 +655,51 @@ StructLoop:
 				)
 			}
 
-			field.Set(reflect.ValueOf(existing.Value))
+			field.Set(g.fieldValue(existing, fieldType, tag))
+			if g.Logger != nil {
+				g.Logger.Debugf(
+					"assigned %s to field %s in %s",
+					existing,
+					o.reflectType.Elem().Field(i).Name,
+					o,
+				)
+			}
+			o.addDep(fieldName, existing)
+			g.emit(Event{Kind: EventAssigned, Object: o, Field: fieldName, Dep: existing})
+			o.addResolution(&Resolution{
+				Field:  fieldName,
+				Kind:   ResolvedNamed,
+				Object: existing,
+			})
+			continue StructLoop
+		}
+
+		// Qualified injects must have been explicitly provided.
+		if tag.Qualifier != "" {
+			existing, err := g.lookupQualified(tag.Qualifier, fieldType)
+			if err != nil {
+				return fmt.Errorf(
+					"%s required by field %s in type %s",
+					err,
+					o.reflectType.Elem().Field(i).Name,
+					o.reflectType,
+				)
+			}
+			if existing == nil && tag.Optional {
+				g.skipOptional(o, fieldName)
+				continue
+			}
+			if existing == nil {
+				return fmt.Errorf(
+					"did not find object of type %s qualified by %s required by field %s in type %s",
+					fieldType,
+					tag.Qualifier,
+					o.reflectType.Elem().Field(i).Name,
+					o.reflectType,
+				)
+			}
+
+			field.Set(g.fieldValue(existing, fieldType, tag))
 			if g.Logger != nil {
 				g.Logger.Debugf(
 					"assigned %s to field %s in %s",
@@ -292,6 +709,12 @@ StructLoop:
 				)
 			}
 			o.addDep(fieldName, existing)
+			g.emit(Event{Kind: EventAssigned, Object: o, Field: fieldName, Dep: existing})
+			o.addResolution(&Resolution{
+				Field:  fieldName,
+				Kind:   ResolvedQualified,
+				Object: existing,
+			})
 			continue StructLoop
 		}
 
@@ -314,14 +737,54 @@ StructLoop:
 				)
 			}
 
-			err := g.Provide(&Object{
+			inlineObject := &Object{
 				Value:    field.Addr().Interface(),
 				private:  true,
 				embedded: o.reflectType.Elem().Field(i).Anonymous,
+				module:   o.module,
+			}
+			if err := g.provide(inlineObject); err != nil {
+				return err
+			}
+			g.emit(Event{
+				Kind:   EventInlineProvided,
+				Object: o,
+				Field:  fieldName,
+				Dep:    inlineObject,
 			})
-			if err != nil {
+			o.addResolution(&Resolution{
+				Field:  fieldName,
+				Kind:   ResolvedInline,
+				Object: inlineObject,
+			})
+			continue
+		}
+
+		// Inline embedded pointers are allocated and then traversed just like
+		// inline struct values.
+		if tag.Inline {
+			newValue := reflect.New(fieldType.Elem())
+			inlineObject := &Object{
+				Value:    newValue.Interface(),
+				private:  true,
+				embedded: true,
+				module:   o.module,
+			}
+			if err := g.provide(inlineObject); err != nil {
 				return err
 			}
+			field.Set(newValue)
+			g.emit(Event{
+				Kind:   EventInlineProvided,
+				Object: o,
+				Field:  fieldName,
+				Dep:    inlineObject,
+			})
+			o.addResolution(&Resolution{
+				Field:  fieldName,
+				Kind:   ResolvedInline,
+				Object: inlineObject,
+			})
 			continue
 		}
 
@@ -348,11 +811,13 @@ StructLoop:
 					o,
 				)
 			}
+			g.emit(Event{Kind: EventMapMade, Object: o, Field: fieldName})
+			o.addResolution(&Resolution{Field: fieldName, Kind: ResolvedMap})
 			continue
 		}
 
-		// Can only inject Pointers from here on.
-		if !isStructPtr(fieldType) {
+		// Can only inject Pointers, functions and channels from here on.
+		if !isStructPtr(fieldType) && !isFuncOrChan(fieldType) {
 			return fmt.Errorf(
 				"found inject tag on unsupported field %s in type %s",
 				o.reflectType.Elem().Field(i).Name,
@@ -360,44 +825,109 @@ StructLoop:
 			)
 		}
 
+		// Functions can't be created, so they must have been provided.
+		if tag.Private && fieldType.Kind() == reflect.Func {
+			return fmt.Errorf(
+				"cannot use private inject on function field %s in type %s",
+				o.reflectType.Elem().Field(i).Name,
+				o.reflectType,
+			)
+		}
+
 		// Unless it's a private inject, we'll look for an existing instance of the
 		// same type.
 		if !tag.Private {
+			var found *Object
 			for _, existing := range g.unnamed {
-				if existing.private {
+				if existing.private || !existing.reflectType.AssignableTo(fieldType) {
 					continue
 				}
-				if existing.reflectType.AssignableTo(fieldType) {
-					field.Set(reflect.ValueOf(existing.Value))
-					if g.Logger != nil {
-						g.Logger.Debugf(
-							"assigned existing %s to field %s in %s",
-							existing,
-							o.reflectType.Elem().Field(i).Name,
-							o,
-						)
-					}
-					o.addDep(fieldName, existing)
-					continue StructLoop
+				// Values of different named func and chan types may be assignable to
+				// the same field, so keep looking for another one.
+				if found == nil {
+					found = existing
+					continue
 				}
+				return fmt.Errorf(
+					"found two assignable values for field %s in type %s. one type "+
+						"%s with value %v and another type %s with value %v",
+					o.reflectType.Elem().Field(i).Name,
+					o.reflectType,
+					found.reflectType,
+					found.Value,
+					existing.reflectType,
+					existing.Value,
+				)
 			}
+			if found != nil {
+				field.Set(g.fieldValue(found, fieldType, tag))
+				if g.Logger != nil {
+					g.Logger.Debugf(
+						"assigned existing %s to field %s in %s",
+						found,
+						o.reflectType.Elem().Field(i).Name,
+						o,
+					)
+				}
+				o.addDep(fieldName, found)
+				g.emit(Event{
+					Kind:   EventAssigned,
+					Object: o,
+					Field:  fieldName,
+					Dep:    found,
+				})
+				o.addResolution(&Resolution{
+					Field:  fieldName,
+					Kind:   ResolvedExisting,
+					Object: found,
+				})
+				continue StructLoop
+			}
+		}
+
+		if tag.Optional && (fieldType.Kind() == reflect.Func ||
+			(g.Strict && !tag.Private && !g.canCreate(fieldType))) {
+			g.skipOptional(o, fieldName)
+			continue
 		}
 
-		newValue := reflect.New(fieldType.Elem())
+		if fieldType.Kind() == reflect.Func {
+			return fmt.Errorf(
+				"found no provided function for field %s in type %s",
+				o.reflectType.Elem().Field(i).Name,
+				o.reflectType,
+			)
+		}
+
+		// In Strict mode we refuse to silently create singletons that were
+		// probably meant to be provided.
+		if g.Strict && !tag.Private && !g.canCreate(fieldType) {
+			return fmt.Errorf(
+				"refusing to create %s for field %s in type %s in strict mode",
+				fieldType,
+				o.reflectType.Elem().Field(i).Name,
+				o.reflectType,
+			)
+		}
+
+		newValue := newValue(fieldType)
 		newObject := &Object{
 			Value:   newValue.Interface(),
 			private: tag.Private,
 			created: true,
 		}
+		if tag.Private {
+			newObject.module = o.module
+		}
 
 		// Add the newly ceated object to the known set of objects.
-		err = g.Provide(newObject)
+		err = g.provide(newObject)
 		if err != nil {
 			return err
 		}
 
 		// Finally assign the newly created object to our field.
-		field.Set(newValue)
+		field.Set(g.fieldValue(newObject, fieldType, tag))
 		if g.Logger != nil {
 			g.Logger.Debugf(
 				"assigned newly created %s to field %s in %s",
@@ -407,13 +937,19 @@ StructLoop:
 			)
 		}
 		o.addDep(fieldName, newObject)
+		g.emit(Event{Kind: EventAssigned, Object: o, Field: fieldName, Dep: newObject})
+		o.addResolution(&Resolution{
+			Field:  fieldName,
+			Kind:   ResolvedCreated,
+			Object: newObject,
+		})
 	}
 	return nil
 }
 
 func (g *Graph) populateUnnamedInterface(o *Object) error {
-	// Ignore named value types.
-	if o.Name != "" && !isStructPtr(o.reflectType) {
+	// Ignore named value types, functions and channels.
+	if !isStructPtr(o.reflectType) {
 		return nil
 	}
 
@@ -422,7 +958,7 @@ func (g *Graph) populateUnnamedInterface(o *Object) error {
 		fieldType := field.Type()
 		fieldTag := o.reflectType.Elem().Field(i).Tag
 		fieldName := o.reflectType.Elem().Field(i).Name
-		tag, err := parseTag(string(fieldTag))
+		tag, err := injecttag.Parse(string(fieldTag))
 		if err != nil {
 			return fmt.Errorf(
 				"unexpected tag format `%s` for field %s in type %s",
@@ -443,6 +979,11 @@ func (g *Graph) populateUnnamedInterface(o *Object) error {
 			continue
 		}
 
+		// The first pass already rejected unexported fields unless allowed.
+		if !field.CanSet() {
+			field = unexportedField(field)
+		}
+
 		// Interface injection can't be private because we can't instantiate new
 		// instances of an interface.
 		if tag.Private {
@@ -458,18 +999,70 @@ func (g *Graph) populateUnnamedInterface(o *Object) error {
 			continue
 		}
 
-		// Named injects must have already been handled in populateExplicit.
-		if tag.Name != "" {
+		// Named and qualified injects must have already been handled in
+		// populateExplicit.
+		if tag.Name != "" || tag.Qualifier != "" {
 			panic(fmt.Sprintf("unhandled named instance with name %s", tag.Name))
 		}
 
+		// Bound interfaces always get the bound implementation.
+		if impl, ok := g.bindings[fieldType]; ok {
+			bound, err := g.bound(impl)
+			if err != nil {
+				return fmt.Errorf(
+					"%s for field %s in type %s",
+					err,
+					o.reflectType.Elem().Field(i).Name,
+					o.reflectType,
+				)
+			}
+			field.Set(g.fieldValue(bound, fieldType, tag))
+			if g.Logger != nil {
+				g.Logger.Debugf(
+					"assigned bound %s to interface field %s in %s",
+					bound,
+					o.reflectType.Elem().Field(i).Name,
+					o,
+				)
+			}
+			o.addDep(fieldName, bound)
+			g.emit(Event{
+				Kind:   EventInterfaceAssigned,
+				Object: o,
+				Field:  fieldName,
+				Dep:    bound,
+			})
+			o.addResolution(&Resolution{
+				Field:  fieldName,
+				Kind:   ResolvedInterface,
+				Object: bound,
+			})
+			continue
+		}
+
 		// Find one, and only one assignable value for the field.
 		var found *Object
+		var rejected []*Candidate
 		for _, existing := range g.unnamed {
 			if existing.private {
+				if existing.reflectType.AssignableTo(fieldType) {
+					rejected = append(rejected, &Candidate{
+						Object: existing,
+						Reason: "private",
+					})
+				}
 				continue
 			}
 			if existing.reflectType.AssignableTo(fieldType) {
+				// Objects embedding the interface only implement it by virtue of
+				// having it injected, so they are consumers and not candidates.
+				if embedsInjected(existing.reflectType, fieldType) {
+					rejected = append(rejected, &Candidate{
+						Object: existing,
+						Reason: "embeds the interface",
+					})
+					continue
+				}
 				if found != nil {
 					return fmt.Errorf(
 						"found two assignable values for field %s in type %s. one type "+
@@ -483,7 +1076,6 @@ func (g *Graph) populateUnnamedInterface(o *Object) error {
 					)
 				}
 				found = existing
-				field.Set(reflect.ValueOf(existing.Value))
 				if g.Logger != nil {
 					g.Logger.Debugf(
 						"assigned existing %s to interface field %s in %s",
@@ -493,10 +1085,20 @@ func (g *Graph) populateUnnamedInterface(o *Object) error {
 					)
 				}
 				o.addDep(fieldName, existing)
+				g.emit(Event{
+					Kind:   EventInterfaceAssigned,
+					Object: o,
+					Field:  fieldName,
+					Dep:    existing,
+				})
 			}
 		}
 
 		// If we didn't find an assignable value, we're missing something.
+		if found == nil && tag.Optional {
+			g.skipOptional(o, fieldName)
+			continue
+		}
 		if found == nil {
 			return fmt.Errorf(
 				"found no assignable value for field %s in type %s",
@@ -504,20 +1106,240 @@ func (g *Graph) populateUnnamedInterface(o *Object) error {
 				o.reflectType,
 			)
 		}
+		field.Set(g.fieldValue(found, fieldType, tag))
+
+		// Named objects are never used for unnamed injects, but they are worth
+		// mentioning when explaining the choice.
+		for _, existing := range g.namedObjects() {
+			if existing.reflectType.AssignableTo(fieldType) {
+				rejected = append(rejected, &Candidate{
+					Object: existing,
+					Reason: "named",
+				})
+			}
+		}
+		o.addResolution(&Resolution{
+			Field:    fieldName,
+			Kind:     ResolvedInterface,
+			Object:   found,
+			Rejected: rejected,
+		})
 	}
 	return nil
 }
 
+// bind the interface type to the given implementation type.
+func (g *Graph) bind(iface, impl reflect.Type) error {
+	if err := g.checkFrozen("bind"); err != nil {
+		return err
+	}
+	if iface.Kind() != reflect.Interface {
+		return fmt.Errorf("cannot bind non interface type %s", iface)
+	}
+	if !impl.AssignableTo(iface) {
+		return fmt.Errorf("cannot bind %s to %s which it does not implement", iface, impl)
+	}
+	if existing, ok := g.bindings[iface]; ok {
+		return fmt.Errorf("%s is already bound to %s", iface, existing)
+	}
+	if g.bindings == nil {
+		g.bindings = make(map[reflect.Type]reflect.Type)
+	}
+	g.bindings[iface] = impl
+	return nil
+}
+
+// bound returns the singleton of the given bound implementation type,
+// creating and populating it if necessary. A binding does not allow creating
+// the implementation in Strict mode.
+func (g *Graph) bound(impl reflect.Type) (*Object, error) {
+	for _, existing := range g.unnamed {
+		if !existing.private && existing.reflectType == impl {
+			return existing, nil
+		}
+	}
+	if !isStructPtr(impl) {
+		return nil, fmt.Errorf("found no provided value for bound type %s", impl)
+	}
+	if g.Strict && !g.canCreate(impl) {
+		return nil, fmt.Errorf("refusing to create bound type %s in strict mode", impl)
+	}
+
+	newObject := &Object{
+		Value:   reflect.New(impl.Elem()).Interface(),
+		created: true,
+	}
+	start := len(g.unnamed)
+	if err := g.provide(newObject); err != nil {
+		return nil, err
+	}
+	if err := g.populateUnnamed(start); err != nil {
+		return nil, err
+	}
+	return newObject, nil
+}
+
+// get returns the Object that an unnamed inject of the given type would be
+// assigned.
+func (g *Graph) get(t reflect.Type) (*Object, error) {
+	if impl, ok := g.bindings[t]; ok {
+		t = impl
+	}
+	var found *Object
+	unnamed, _ := g.view()
+	for _, existing := range unnamed {
+		if existing.private || !existing.reflectType.AssignableTo(t) {
+			continue
+		}
+		if t.Kind() == reflect.Interface && embedsInjected(existing.reflectType, t) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("found two assignable values for type %s", t)
+		}
+		found = existing
+	}
+	if found == nil {
+		return nil, fmt.Errorf("found no assignable value for type %s", t)
+	}
+	return found, nil
+}
+
+// Explain returns how the named field of the given Object was resolved. It is
+// only meaningful after Populate.
+func (g *Graph) Explain(o *Object, field string) (*Resolution, error) {
+	if !isStructPtr(o.reflectType) {
+		return nil, fmt.Errorf("cannot explain fields of %s", o)
+	}
+	if _, ok := o.reflectType.Elem().FieldByName(field); !ok {
+		return nil, fmt.Errorf("no field %s in %s", field, o)
+	}
+	r := g.edges(o).resolutions[field]
+	if r == nil {
+		return nil, fmt.Errorf("field %s in %s was not resolved", field, o)
+	}
+	return r, nil
+}
+
+// Resolutions returns copies of how the fields of the given Object were
+// resolved, sorted by field name. It is only meaningful after Populate.
+func (g *Graph) Resolutions(o *Object) []*Resolution {
+	// Lazy fields are resolved holding the lock.
+	if g.snapshot() == nil {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+	}
+	e := g.edges(o)
+	resolutions := make([]*Resolution, 0, len(e.resolutions))
+	for _, name := range sortedResolutionNames(e.resolutions) {
+		r := *e.resolutions[name]
+		resolutions = append(resolutions, &r)
+	}
+	return resolutions
+}
+
+// canCreate checks if the Graph may create a value of the given type when it
+// is in Strict mode.
+func (g *Graph) canCreate(t reflect.Type) bool {
+	if t.Implements(creatableType) {
+		return true
+	}
+	for _, allowed := range g.AllowCreate {
+		if allowed == t || (t.Kind() == reflect.Ptr && allowed == t.Elem()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Created returns the objects that were created by the Graph rather than
+// provided to it, in the order they were created.
+func (g *Graph) Created() []*Object {
+	var objects []*Object
+	unnamed, _ := g.view()
+	for _, o := range unnamed {
+		if o.created {
+			objects = append(objects, o)
+		}
+	}
+	return objects
+}
+
+// Unused returns the provided objects that were not injected into any field
+// and are not marked as a Root. Objects a lazy field may still resolve to count
+// as used. It is only meaningful after Populate.
+func (g *Graph) Unused() []*Object {
+	unnamed, named := g.view()
+	used := make(map[*Object]bool)
+	for _, objects := range [][]*Object{unnamed, named} {
+		for _, o := range objects {
+			e := g.edges(o)
+			for _, dep := range e.fields {
+				used[dep] = true
+			}
+			for _, r := range e.resolutions {
+				if r.Kind != ResolvedLazy || r.Object != nil {
+					continue
+				}
+				for _, c := range lazyCandidates(o, r, unnamed, named) {
+					used[c] = true
+				}
+			}
+		}
+	}
+
+	var unused []*Object
+	for _, o := range unnamed {
+		if o.Root || o.private || o.created || o.embedded || used[o] {
+			continue
+		}
+		unused = append(unused, o)
+	}
+	for _, o := range named {
+		if o.Root || used[o] {
+			continue
+		}
+		unused = append(unused, o)
+	}
+	return unused
+}
+
+// allObjects returns all known objects including embedded ones.
+func (g *Graph) allObjects() []*Object {
+	objects := make([]*Object, 0, len(g.unnamed)+len(g.named))
+	objects = append(objects, g.unnamed...)
+	return append(objects, g.namedObjects()...)
+}
+
+// namedObjects returns the named and qualified objects in the order they were
+// provided.
+func (g *Graph) namedObjects() []*Object {
+	objects := make([]*Object, 0, len(g.named))
+	for _, o := range g.named {
+		objects = append(objects, o)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].seq < objects[j].seq
+	})
+	return objects
+}
+
 // Objects returns all known objects, named as well as unnamed. The returned
 // elements are not in a stable order.
 func (g *Graph) Objects() []*Object {
-	objects := make([]*Object, 0, len(g.unnamed)+len(g.named))
-	for _, o := range g.unnamed {
+	// Lazy fields are resolved holding the lock.
+	if g.snapshot() == nil {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+	}
+	unnamed, named := g.view()
+	objects := make([]*Object, 0, len(unnamed)+len(named))
+	for _, o := range unnamed {
 		if !o.embedded {
 			objects = append(objects, o)
 		}
 	}
-	for _, o := range g.named {
+	for _, o := range named {
 		if !o.embedded {
 			objects = append(objects, o)
 		}
@@ -530,47 +1352,74 @@ func (g *Graph) Objects() []*Object {
 	return objects
 }
 
-var (
-	injectOnly    = &tag{}
-	injectPrivate = &tag{Private: true}
-	injectInline  = &tag{Inline: true}
-)
+// SortedObjects returns all known objects like Objects, but in the order they
+// were provided, including the ones the Graph created.
+func (g *Graph) SortedObjects() []*Object {
+	objects := g.Objects()
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].seq < objects[j].seq
+	})
+	return objects
+}
+
+var creatableType = reflect.TypeOf((*Creatable)(nil)).Elem()
 
-type tag struct {
-	Name    string
-	Inline  bool
-	Private bool
+func isStructPtr(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
 }
 
-func parseTag(t string) (*tag, error) {
-	found, value, err := structtag.Extract("inject", t)
-	if err != nil {
-		return nil, err
-	}
-	if !found {
-		return nil, nil
+// embedsInjected checks if the struct pointer type embeds an injected
+// interface field that satisfies the given interface.
+func embedsInjected(t, iface reflect.Type) bool {
+	if !isStructPtr(t) {
+		return false
 	}
-	if value == "" {
-		return injectOnly, nil
-	}
-	if value == "inline" {
-		return injectInline, nil
+	for i := 0; i < t.Elem().NumField(); i++ {
+		f := t.Elem().Field(i)
+		if !f.Anonymous || f.Type.Kind() != reflect.Interface || !f.Type.Implements(iface) {
+			continue
+		}
+		if tag, err := injecttag.Parse(string(f.Tag)); err == nil && tag != nil {
+			return true
+		}
 	}
-	if value == "private" {
-		return injectPrivate, nil
+	return false
+}
+
+// unexportedField returns a settable version of an addressable unexported
+// field. This relies on unsafe and bypasses the usual visibility rules.
+func unexportedField(field reflect.Value) reflect.Value {
+	return reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+}
+
+// field returns a settable version of the named field of a struct pointer
+// Object, along with its description.
+func (o *Object) field(name string) (reflect.Value, reflect.StructField) {
+	structField, _ := o.reflectType.Elem().FieldByName(name)
+	field := o.reflectValue.Elem().FieldByIndex(structField.Index)
+	if !field.CanSet() {
+		field = unexportedField(field)
 	}
-	return &tag{Name: value}, nil
+	return field, structField
 }
 
-func isStructPtr(t reflect.Type) bool {
-	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
+func isFuncOrChan(t reflect.Type) bool {
+	return t.Kind() == reflect.Func || t.Kind() == reflect.Chan
+}
+
+// newValue creates a new struct pointer or an unbuffered channel.
+func newValue(t reflect.Type) reflect.Value {
+	if t.Kind() == reflect.Chan {
+		return reflect.MakeChan(t, 0)
+	}
+	return reflect.New(t.Elem())
 }
 
 func isNilOrZero(v reflect.Value, t reflect.Type) bool {
 	switch v.Kind() {
 	default:
 		return reflect.DeepEqual(v.Interface(), reflect.Zero(t).Interface())
-	case reflect.Interface, reflect.Ptr:
+	case reflect.Interface, reflect.Ptr, reflect.Func, reflect.Chan:
 		return v.IsNil()
 	}
 }
@@ -1,8 +1,12 @@
 package inject_test
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
+	"net"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -881,6 +885,57 @@ func TestGraphObjects(t *testing.T) {
 	})
 }
 
+func TestGraphSortedObjects(t *testing.T) {
+	var g inject.Graph
+	err := g.Provide(
+		&inject.Object{Value: &TypeNestedStruct{}, Name: "foo"},
+		&inject.Object{Value: &TypeForGraphObjects{}},
+	)
+	ensure.Nil(t, err)
+	ensure.Nil(t, g.Populate())
+
+	var actual []string
+	for _, o := range g.SortedObjects() {
+		actual = append(actual, fmt.Sprint(o))
+	}
+
+	ensure.DeepEqual(t, actual, []string{
+		"*inject_test.TypeNestedStruct named foo",
+		"*inject_test.TypeForGraphObjects",
+		"*inject_test.TypeAnswerStruct",
+		`*struct { B *inject_test.TypeNestedStruct "inject:\"\"" }`,
+		"*inject_test.TypeNestedStruct",
+	})
+}
+
+type recordingLogger struct {
+	logs []string
+}
+
+func (l *recordingLogger) Debugf(f string, v ...interface{}) {
+	l.logs = append(l.logs, fmt.Sprintf(f, v...))
+}
+
+func TestPopulateOrderIsDeterministic(t *testing.T) {
+	populate := func() []string {
+		var l recordingLogger
+		g := inject.Graph{Logger: &l}
+		for i := 0; i < 10; i++ {
+			ensure.Nil(t, g.Provide(&inject.Object{
+				Value: &TypeNestedStruct{},
+				Name:  fmt.Sprintf("nested %d", i),
+			}))
+		}
+		ensure.Nil(t, g.Populate())
+		return l.logs
+	}
+
+	expected := populate()
+	for i := 0; i < 10; i++ {
+		ensure.DeepEqual(t, populate(), expected)
+	}
+}
+
 type logger struct {
 	Expected []string
 	T        testing.TB
@@ -994,3 +1049,363 @@ func TestForSameNameButDifferentPackage(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+type TypeForStrictCreatable struct{}
+
+func (*TypeForStrictCreatable) InjectCreatable() {}
+
+type TypeForStrict struct {
+	A *TypeAnswerStruct       `inject:""`
+	C *TypeForStrictCreatable `inject:""`
+}
+
+func TestStrictRefusesCreate(t *testing.T) {
+	g := inject.Graph{Strict: true}
+	var v TypeForStrict
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	err := g.Populate()
+	ensure.NotNil(t, err)
+	const msg = "refusing to create *inject_test.TypeAnswerStruct for field A in type *inject_test.TypeForStrict in strict mode"
+	ensure.DeepEqual(t, err.Error(), msg)
+}
+
+func TestStrictAllowsProvidedAndAllowed(t *testing.T) {
+	g := inject.Graph{
+		Strict:      true,
+		AllowCreate: []reflect.Type{reflect.TypeOf(TypeAnswerStruct{})},
+	}
+	var v TypeForStrict
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+	ensure.True(t, v.A != nil)
+	ensure.True(t, v.C != nil)
+}
+
+func TestStrictAllowsPrivate(t *testing.T) {
+	g := inject.Graph{Strict: true}
+	var v struct {
+		A *TypeAnswerStruct `inject:"private"`
+	}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+	ensure.True(t, v.A != nil)
+}
+
+func TestCreated(t *testing.T) {
+	var g inject.Graph
+	var v TypeForStrict
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+
+	var actual []string
+	for _, o := range g.Created() {
+		actual = append(actual, fmt.Sprint(o))
+	}
+	ensure.DeepEqual(t, actual, []string{
+		"*inject_test.TypeAnswerStruct",
+		"*inject_test.TypeForStrictCreatable",
+	})
+}
+
+func TestUnused(t *testing.T) {
+	var g inject.Graph
+	var v TypeNestedStruct
+	err := g.Provide(
+		&inject.Object{Value: &v, Root: true},
+		&inject.Object{Value: &TypeAnswerStruct{}},
+		&inject.Object{Value: &TypeAnswerStruct{}, Name: "foo"},
+	)
+	ensure.Nil(t, err)
+	ensure.Nil(t, g.Populate())
+
+	var actual []string
+	for _, o := range g.Unused() {
+		actual = append(actual, fmt.Sprint(o))
+	}
+	ensure.DeepEqual(t, actual, []string{"*inject_test.TypeAnswerStruct named foo"})
+}
+
+func TestNoUnused(t *testing.T) {
+	g := inject.Graph{NoUnused: true}
+	err := g.Provide(
+		&inject.Object{Value: &TypeNestedStruct{}, Root: true},
+		&inject.Object{Value: &TypeAnswerStruct{}, Name: "foo"},
+	)
+	ensure.Nil(t, err)
+	err = g.Populate()
+	ensure.NotNil(t, err)
+	const msg = "provided objects were never injected: *inject_test.TypeAnswerStruct named foo"
+	ensure.DeepEqual(t, err.Error(), msg)
+}
+
+type TypeForExplainCreated struct{}
+
+type TypeForExplain struct {
+	Named     *TypeAnswerStruct      `inject:"foo"`
+	Existing  *TypeAnswerStruct      `inject:""`
+	Created   *TypeForExplainCreated `inject:""`
+	Private   *TypeNestedStruct      `inject:"private"`
+	Interface Answerable             `inject:""`
+	Map       map[string]int         `inject:"private"`
+	Preset    *TypeAnswerStruct      `inject:""`
+	Inline    struct{}               `inject:"inline"`
+	Ignored   *TypeAnswerStruct
+}
+
+func TestExplain(t *testing.T) {
+	var g inject.Graph
+	named := &inject.Object{Value: &TypeAnswerStruct{}, Name: "foo"}
+	existing := &inject.Object{Value: &TypeAnswerStruct{}}
+	v := &TypeForExplain{Preset: &TypeAnswerStruct{}}
+	root := &inject.Object{Value: v}
+	ensure.Nil(t, g.Provide(named, existing, root))
+	ensure.Nil(t, g.Populate())
+
+	kinds := map[string]inject.ResolutionKind{
+		"Named":    inject.ResolvedNamed,
+		"Existing": inject.ResolvedExisting,
+		"Created":  inject.ResolvedCreated,
+		"Private":  inject.ResolvedCreated,
+		"Map":      inject.ResolvedMap,
+		"Preset":   inject.ResolvedPreset,
+		"Inline":   inject.ResolvedInline,
+	}
+	for field, kind := range kinds {
+		r, err := g.Explain(root, field)
+		ensure.Nil(t, err, field)
+		ensure.DeepEqual(t, r.Kind, kind, field)
+	}
+
+	r, err := g.Explain(root, "Named")
+	ensure.Nil(t, err)
+	ensure.True(t, r.Object == named)
+
+	r, err = g.Explain(root, "Interface")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, r.Kind, inject.ResolvedInterface)
+	ensure.True(t, r.Object == existing)
+	var rejected []string
+	for _, c := range r.Rejected {
+		rejected = append(rejected, fmt.Sprintf("%s %s", c.Object, c.Reason))
+	}
+	ensure.SameElements(t, rejected, []string{
+		"*inject_test.TypeNestedStruct private",
+		"*inject_test.TypeAnswerStruct named foo named",
+	})
+
+	_, err = g.Explain(root, "Ignored")
+	ensure.Err(t, err, regexp.MustCompile("field Ignored in \\*inject_test.TypeForExplain was not resolved"))
+	_, err = g.Explain(root, "Missing")
+	ensure.Err(t, err, regexp.MustCompile("no field Missing in \\*inject_test.TypeForExplain"))
+}
+
+type eventLogger []string
+
+func (l *eventLogger) Event(e inject.Event) {
+	s := fmt.Sprintf("%s %s", e.Kind, e.Object)
+	if e.Field != "" {
+		s += " " + e.Field
+	}
+	if e.Dep != nil {
+		s += fmt.Sprintf(" %s", e.Dep)
+	}
+	*l = append(*l, s)
+}
+
+func TestInjectEventLogging(t *testing.T) {
+	var events eventLogger
+	g := inject.Graph{EventLogger: &events}
+	var v TypeForLogging
+
+	err := g.Provide(
+		&inject.Object{Value: &TypeForLoggingCreated{}, Name: "name_for_logging"},
+		&inject.Object{Value: &v},
+	)
+	ensure.Nil(t, err)
+	ensure.Nil(t, g.Populate())
+	ensure.DeepEqual(t, []string(events), []string{
+		"provided *inject_test.TypeForLoggingCreated named name_for_logging",
+		"provided *inject_test.TypeForLogging",
+		"inline provided *inject_test.TypeForLogging TypeForLoggingEmbedded *inject_test.TypeForLoggingEmbedded",
+		"created *inject_test.TypeForLoggingCreated",
+		"assigned *inject_test.TypeForLogging TypeForLoggingCreated *inject_test.TypeForLoggingCreated",
+		"assigned *inject_test.TypeForLoggingEmbedded TypeForLoggingCreated *inject_test.TypeForLoggingCreated",
+		"assigned *inject_test.TypeForLoggingEmbedded TypeForLoggingCreatedNamed *inject_test.TypeForLoggingCreated named name_for_logging",
+		"map made *inject_test.TypeForLoggingEmbedded Map",
+		"interface assigned *inject_test.TypeForLoggingEmbedded TypeForLoggingInterface *inject_test.TypeForLoggingCreated",
+	})
+}
+
+type TypeWithUnexportedFields struct {
+	a *TypeAnswerStruct `inject:""`
+	b Answerable        `inject:""`
+	c map[string]int    `inject:"private"`
+}
+
+func TestInjectUnexported(t *testing.T) {
+	g := inject.Graph{Unexported: true}
+	var v TypeWithUnexportedFields
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+	ensure.True(t, v.a != nil)
+	ensure.True(t, v.b == v.a)
+	ensure.True(t, v.c != nil)
+}
+
+type TypeForEmbeddedBase struct {
+	A *TypeAnswerStruct `inject:""`
+}
+
+type TypeWithEmbeddedPointer struct {
+	*TypeForEmbeddedBase `inject:""`
+}
+
+type TypeWithInlineEmbeddedPointer struct {
+	*TypeForEmbeddedBase `inject:"inline"`
+}
+
+func TestInjectEmbeddedPointer(t *testing.T) {
+	var g inject.Graph
+	var v TypeWithEmbeddedPointer
+	var inline TypeWithInlineEmbeddedPointer
+	ensure.Nil(t, g.Provide(
+		&inject.Object{Value: &v},
+		&inject.Object{Value: &inline},
+	))
+	ensure.Nil(t, g.Populate())
+	ensure.True(t, v.TypeForEmbeddedBase != nil)
+	ensure.True(t, v.A != nil)
+	ensure.True(t, inline.TypeForEmbeddedBase != nil)
+	ensure.True(t, inline.TypeForEmbeddedBase != v.TypeForEmbeddedBase)
+	ensure.True(t, inline.A == v.A)
+
+	var actual []string
+	for _, o := range g.Objects() {
+		actual = append(actual, fmt.Sprint(o))
+	}
+	ensure.SameElements(t, actual, []string{
+		"*inject_test.TypeWithEmbeddedPointer",
+		"*inject_test.TypeWithInlineEmbeddedPointer",
+		"*inject_test.TypeForEmbeddedBase",
+		"*inject_test.TypeAnswerStruct",
+	})
+}
+
+type TypeWithEmbeddedInterface struct {
+	Answerable `inject:""`
+}
+
+type TypeWithOtherEmbeddedInterface struct {
+	Answerable `inject:""`
+}
+
+func TestInjectEmbeddedInterface(t *testing.T) {
+	var g inject.Graph
+	a := &TypeAnswerStruct{answer: 42}
+	var v TypeWithEmbeddedInterface
+	var other TypeWithOtherEmbeddedInterface
+	root := &inject.Object{Value: &v}
+	ensure.Nil(t, g.Provide(
+		root,
+		&inject.Object{Value: &other},
+		&inject.Object{Value: a},
+	))
+	ensure.Nil(t, g.Populate())
+	ensure.DeepEqual(t, v.Answer(), 42)
+	ensure.DeepEqual(t, other.Answer(), 42)
+
+	r, err := g.Explain(root, "Answerable")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, len(r.Rejected), 2)
+	ensure.DeepEqual(t, r.Rejected[0].Reason, "embeds the interface")
+}
+
+type TypeDialer func(addr string) (net.Conn, error)
+
+type TypeWithFuncs struct {
+	Now    func() time.Time `inject:"clock"`
+	Dial   TypeDialer       `inject:""`
+	Events chan string      `inject:""`
+	Own    chan string      `inject:"private"`
+	Nested *TypeWithChan    `inject:""`
+}
+
+type TypeWithChan struct {
+	Events chan string `inject:""`
+}
+
+func TestInjectFuncsAndChans(t *testing.T) {
+	var g inject.Graph
+	var v TypeWithFuncs
+	now := time.Unix(42, 0)
+	dialed := errors.New("dialed")
+	err := g.Provide(
+		&inject.Object{Value: &v},
+		&inject.Object{Value: func() time.Time { return now }, Name: "clock"},
+		&inject.Object{Value: TypeDialer(func(string) (net.Conn, error) { return nil, dialed })},
+	)
+	ensure.Nil(t, err)
+	ensure.Nil(t, g.Populate())
+	ensure.DeepEqual(t, v.Now(), now)
+	_, err = v.Dial("localhost:80")
+	ensure.DeepEqual(t, err, dialed)
+	ensure.True(t, v.Events != nil)
+	ensure.True(t, v.Events == v.Nested.Events)
+	ensure.True(t, v.Own != nil && v.Own != v.Events)
+}
+
+func TestInjectFuncMissing(t *testing.T) {
+	var v struct {
+		Dial TypeDialer `inject:""`
+	}
+	err := inject.Populate(&v)
+	ensure.Err(t, err, regexp.MustCompile("^found no provided function for field Dial in type"))
+}
+
+func TestInjectFuncPrivate(t *testing.T) {
+	var v struct {
+		Dial TypeDialer `inject:"private"`
+	}
+	err := inject.Populate(&v)
+	ensure.Err(t, err, regexp.MustCompile("^cannot use private inject on function field Dial in type"))
+}
+
+func TestProvideTwoFuncsOfTheSameType(t *testing.T) {
+	var g inject.Graph
+	dial := TypeDialer(func(string) (net.Conn, error) { return nil, nil })
+	err := g.Provide(&inject.Object{Value: dial}, &inject.Object{Value: dial})
+	ensure.Err(t, err, regexp.MustCompile("^provided two unnamed instances of type inject_test.TypeDialer$"))
+}
+
+type TypeEvents chan string
+
+func TestInjectTwoAssignableFuncs(t *testing.T) {
+	var g inject.Graph
+	var v struct {
+		Count func() int `inject:""`
+	}
+	type counter func() int
+	err := g.Provide(
+		&inject.Object{Value: &v},
+		&inject.Object{Value: counter(func() int { return 1 })},
+		&inject.Object{Value: func() int { return 2 }},
+	)
+	ensure.Nil(t, err)
+	err = g.Populate()
+	ensure.Err(t, err, regexp.MustCompile("^found two assignable values for field Count in type"))
+}
+
+func TestInjectTwoAssignableChans(t *testing.T) {
+	var g inject.Graph
+	var v struct {
+		Events chan string `inject:""`
+	}
+	err := g.Provide(
+		&inject.Object{Value: &v},
+		&inject.Object{Value: make(TypeEvents)},
+		&inject.Object{Value: make(chan string)},
+	)
+	ensure.Nil(t, err)
+	err = g.Populate()
+	ensure.Err(t, err, regexp.MustCompile("^found two assignable values for field Events in type"))
+}
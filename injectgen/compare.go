@@ -0,0 +1,127 @@
+package injectgen
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Compare checks that two object graphs have the same wiring. Pointers are
+// matched up as the graphs are walked, and the same pointer in one graph must
+// always correspond to the same pointer in the other. Other values must be
+// equal.
+func Compare(a, b interface{}) error {
+	c := &comparer{
+		forward:  make(map[pointer]uintptr),
+		backward: make(map[pointer]uintptr),
+	}
+	return c.compare("", reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+type pointer struct {
+	typ  reflect.Type
+	addr uintptr
+}
+
+type comparer struct {
+	forward  map[pointer]uintptr
+	backward map[pointer]uintptr
+}
+
+func (c *comparer) compare(path string, a, b reflect.Value) error {
+	if a.IsValid() != b.IsValid() {
+		return fmt.Errorf("%s: only one value is valid", pathOrRoot(path))
+	}
+	if !a.IsValid() {
+		return nil
+	}
+	if a.Type() != b.Type() {
+		return fmt.Errorf("%s: type %s does not match %s", pathOrRoot(path), a.Type(), b.Type())
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				return fmt.Errorf("%s: only one value is nil", pathOrRoot(path))
+			}
+			return nil
+		}
+		pa := pointer{typ: a.Type(), addr: a.Pointer()}
+		pb := pointer{typ: b.Type(), addr: b.Pointer()}
+		fa, seenA := c.forward[pa]
+		fb, seenB := c.backward[pb]
+		if seenA || seenB {
+			if fa != pb.addr || fb != pa.addr {
+				return fmt.Errorf("%s: wired to different objects", pathOrRoot(path))
+			}
+			return nil
+		}
+		c.forward[pa] = pb.addr
+		c.backward[pb] = pa.addr
+		return c.compare(path, a.Elem(), b.Elem())
+	case reflect.Interface:
+		if a.IsNil() != b.IsNil() {
+			return fmt.Errorf("%s: only one value is nil", pathOrRoot(path))
+		}
+		return c.compare(path, a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			name := a.Type().Field(i).Name
+			if path != "" {
+				name = path + "." + name
+			}
+			if err := c.compare(name, a.Field(i), b.Field(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+		if a.IsNil() != b.IsNil() {
+			return fmt.Errorf("%s: only one value is nil", pathOrRoot(path))
+		}
+		if a.Kind() == reflect.Slice || a.Kind() == reflect.Map {
+			if a.Len() != b.Len() {
+				return fmt.Errorf("%s: length %d does not match %d", pathOrRoot(path), a.Len(), b.Len())
+			}
+		}
+		if a.Kind() != reflect.Slice {
+			return nil
+		}
+		fallthrough
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			if err := c.compare(fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Bool:
+		return c.equal(path, a.Bool() == b.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return c.equal(path, a.Int() == b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr:
+		return c.equal(path, a.Uint() == b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return c.equal(path, a.Float() == b.Float())
+	case reflect.Complex64, reflect.Complex128:
+		return c.equal(path, a.Complex() == b.Complex())
+	case reflect.String:
+		return c.equal(path, a.String() == b.String())
+	}
+	return nil
+}
+
+func (c *comparer) equal(path string, equal bool) error {
+	if !equal {
+		return fmt.Errorf("%s: values are not equal", pathOrRoot(path))
+	}
+	return nil
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "root"
+	}
+	return path
+}
@@ -0,0 +1,731 @@
+// Package injectgen generates plain Go code that wires an object graph the same
+// way inject.Graph.Populate would, without using reflection at runtime.
+//
+// The input package declares a root struct type along with functions providing
+// the objects to seed the graph with. Provider functions take no arguments,
+// return a single value and are marked with a directive in their doc comment:
+//
+//	//inject:provide
+//	func newTransport() *http.Transport { ... }
+//
+//	//inject:provide dev logger
+//	func newLogger() *log.Logger { ... }
+//
+// The first form provides an unnamed object, the second provides an object
+// named "dev logger". The same singleton, private, inline and named semantics
+// as Graph.Populate apply, and errors are reported when generating instead of
+// when populating. Since only the declared types are known when generating,
+// provider functions should return their concrete types.
+package injectgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/facebookgo/inject/internal/injecttag"
+	"golang.org/x/tools/go/packages"
+)
+
+const directive = "//inject:provide"
+
+// Options for Generate.
+type Options struct {
+	Root string // Name of the root struct type.
+	Func string // Optional, name of the generated function, defaults to "Inject" + Root.
+
+	// Optional, if true unexported fields will be injected, matching a Graph
+	// with Unexported set. Only fields of types in the generated package can be
+	// injected this way.
+	Unexported bool
+}
+
+// Result of Generate.
+type Result struct {
+	Code []byte // The generated wiring function.
+	Test []byte // A test comparing the generated wiring with Graph.Populate.
+}
+
+// Generate loads the package matching the given pattern and generates the
+// wiring for its root struct.
+func Generate(pattern string, opts Options) (*Result, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax |
+			packages.NeedTypesInfo,
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package matching %s but found %d", pattern, len(pkgs))
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) != 0 {
+		return nil, pkg.Errors[0]
+	}
+
+	if opts.Root == "" {
+		return nil, fmt.Errorf("no root type specified")
+	}
+	if opts.Func == "" {
+		opts.Func = "Inject" + opts.Root
+	}
+
+	g := &generator{
+		opts:    opts,
+		pkg:     pkg.Types,
+		imports: make(map[string]string),
+		named:   make(map[string]*node),
+	}
+	if err := g.seed(pkg, opts.Root); err != nil {
+		return nil, err
+	}
+	if err := g.populate(); err != nil {
+		return nil, err
+	}
+
+	code, err := g.code(opts)
+	if err != nil {
+		return nil, err
+	}
+	test, err := g.test(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Code: code, Test: test}, nil
+}
+
+// A node is the static counterpart of an inject.Object.
+type node struct {
+	expr     string // Go expression referring to the value.
+	typ      types.Type
+	name     string
+	provider string // Name of the providing function, if any.
+	private  bool
+	created  bool
+	guarded  bool // If true, existing field values must not be overwritten.
+}
+
+// String representation matching inject.Object.
+func (n *node) String() string {
+	s := n.typ.String()
+	if n.name != "" {
+		s += " named " + n.name
+	}
+	return s
+}
+
+// structType returns the struct for the node if it has one.
+func (n *node) structType() *types.Struct {
+	t := n.typ
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	st, _ := t.Underlying().(*types.Struct)
+	return st
+}
+
+type generator struct {
+	opts     Options
+	pkg      *types.Package
+	imports  map[string]string // Import path to package name.
+	root     *node
+	named    map[string]*node
+	nodes    []*node // All nodes in populate order, named ones first.
+	unnamed  []*node
+	decls    []string
+	stmts    []string
+	nextNode int
+}
+
+func (g *generator) seed(pkg *packages.Package, root string) error {
+	obj := pkg.Types.Scope().Lookup(root)
+	if obj == nil {
+		return fmt.Errorf("root type %s not found in %s", root, pkg.PkgPath)
+	}
+	if _, ok := obj.Type().Underlying().(*types.Struct); !ok {
+		return fmt.Errorf("root type %s is not a struct", root)
+	}
+	g.root = &node{expr: "root", typ: types.NewPointer(obj.Type())}
+	g.unnamed = append(g.unnamed, g.root)
+	g.decls = append(g.decls, fmt.Sprintf("root := new(%s)", g.typeString(obj.Type())))
+
+	var providers []*ast.FuncDecl
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if ok && fn.Doc != nil && fn.Recv == nil {
+				for _, c := range fn.Doc.List {
+					if c.Text == directive || strings.HasPrefix(c.Text, directive+" ") {
+						providers = append(providers, fn)
+						break
+					}
+				}
+			}
+		}
+	}
+
+	for _, fn := range providers {
+		var name string
+		for _, c := range fn.Doc.List {
+			if strings.HasPrefix(c.Text, directive) {
+				name = strings.TrimSpace(strings.TrimPrefix(c.Text, directive))
+			}
+		}
+
+		sig := pkg.TypesInfo.Defs[fn.Name].Type().(*types.Signature)
+		if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+			return fmt.Errorf(
+				"provider %s must take no arguments and return a single value",
+				fn.Name.Name,
+			)
+		}
+
+		n := &node{
+			expr:     fmt.Sprintf("p%d", g.nextNode),
+			typ:      sig.Results().At(0).Type(),
+			name:     name,
+			provider: fn.Name.Name,
+			guarded:  true,
+		}
+		g.nextNode++
+		g.decls = append(g.decls, fmt.Sprintf("%s := %s()", n.expr, fn.Name.Name))
+
+		if name == "" {
+			if !isStructPtr(n.typ) && !isFuncOrChan(n.typ) {
+				return fmt.Errorf(
+					"expected unnamed provider %s to return a pointer to a struct, "+
+						"a function or a channel but got type %s",
+					fn.Name.Name,
+					n.typ,
+				)
+			}
+			for _, existing := range g.unnamed {
+				if types.Identical(existing.typ, n.typ) {
+					return fmt.Errorf("provided two unnamed instances of type %s", n.typ)
+				}
+			}
+			g.unnamed = append(g.unnamed, n)
+		} else {
+			if g.named[name] != nil {
+				return fmt.Errorf("provided two instances named %s", name)
+			}
+			g.named[name] = n
+			g.nodes = append(g.nodes, n)
+		}
+	}
+	return nil
+}
+
+// populate mirrors Graph.Populate.
+func (g *generator) populate() error {
+	for _, n := range g.nodes {
+		if err := g.populateExplicit(n); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < len(g.unnamed); i++ {
+		n := g.unnamed[i]
+		g.nodes = append(g.nodes, n)
+		if err := g.populateExplicit(n); err != nil {
+			return err
+		}
+	}
+	for _, n := range g.nodes {
+		if err := g.populateUnnamedInterface(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// populateExplicit mirrors Graph.populateExplicit.
+func (g *generator) populateExplicit(n *node) error {
+	st := n.structType()
+	if st == nil || (n.name != "" && !isStructPtr(n.typ)) {
+		return nil
+	}
+
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		fieldType := field.Type()
+		tag, err := injecttag.Parse(st.Tag(i))
+		if err != nil {
+			return fmt.Errorf(
+				"unexpected tag format `%s` for field %s in type %s",
+				st.Tag(i),
+				field.Name(),
+				n.typ,
+			)
+		}
+
+		// Skip fields without a tag.
+		if tag == nil {
+			continue
+		}
+
+		if !field.Exported() {
+			if !g.opts.Unexported {
+				return fmt.Errorf(
+					"inject requested on unexported field %s in type %s",
+					field.Name(),
+					n.typ,
+				)
+			}
+			if field.Pkg() != g.pkg {
+				return fmt.Errorf(
+					"cannot inject unexported field %s in type %s from package %s",
+					field.Name(),
+					n.typ,
+					g.pkg.Path(),
+				)
+			}
+		}
+
+		_, isStruct := fieldType.Underlying().(*types.Struct)
+		embeddedPtr := field.Anonymous() && isStructPtr(fieldType)
+		if tag.Inline && !isStruct && !embeddedPtr {
+			return fmt.Errorf(
+				"inline requested on non inlined field %s in type %s",
+				field.Name(),
+				n.typ,
+			)
+		}
+
+		if tag.Lazy {
+			return fmt.Errorf(
+				"lazy inject on field %s in type %s is not supported",
+				field.Name(),
+				n.typ,
+			)
+		}
+
+		if tag.Qualifier != "" {
+			return fmt.Errorf(
+				"qualifier inject on field %s in type %s is not supported",
+				field.Name(),
+				n.typ,
+			)
+		}
+
+		if tag.Intercept != "" {
+			return fmt.Errorf(
+				"intercepted inject on field %s in type %s is not supported",
+				field.Name(),
+				n.typ,
+			)
+		}
+
+		if tag.Optional {
+			return fmt.Errorf(
+				"optional inject on field %s in type %s is not supported",
+				field.Name(),
+				n.typ,
+			)
+		}
+
+		fieldExpr := n.expr + "." + field.Name()
+
+		// Named injects must have been explicitly provided.
+		if tag.Name != "" {
+			existing := g.named[tag.Name]
+			if existing == nil {
+				return fmt.Errorf(
+					"did not find object named %s required by field %s in type %s",
+					tag.Name,
+					field.Name(),
+					n.typ,
+				)
+			}
+			if !types.AssignableTo(existing.typ, fieldType) {
+				return fmt.Errorf(
+					"object named %s of type %s is not assignable to field %s (%s) in type %s",
+					tag.Name,
+					fieldType,
+					field.Name(),
+					existing.typ,
+					n.typ,
+				)
+			}
+			g.assign(n, fieldExpr, fieldType, existing.expr)
+			continue
+		}
+
+		if isStruct {
+			if tag.Private {
+				return fmt.Errorf(
+					"cannot use private inject on inline struct on field %s in type %s",
+					field.Name(),
+					n.typ,
+				)
+			}
+			if !tag.Inline {
+				return fmt.Errorf(
+					"inline struct on field %s in type %s requires an explicit \"inline\" tag",
+					field.Name(),
+					n.typ,
+				)
+			}
+			g.unnamed = append(g.unnamed, &node{
+				expr:    fieldExpr,
+				typ:     types.NewPointer(fieldType),
+				private: true,
+				guarded: n.guarded,
+			})
+			continue
+		}
+
+		if tag.Inline {
+			inline := g.create(fieldType, true)
+			g.assign(n, fieldExpr, fieldType, inline.expr)
+			continue
+		}
+
+		switch fieldType.Underlying().(type) {
+		case *types.Interface:
+			// Interface injection is handled in a second pass.
+			continue
+		case *types.Map:
+			if !tag.Private {
+				return fmt.Errorf(
+					"inject on map field %s in type %s must be named or private",
+					field.Name(),
+					n.typ,
+				)
+			}
+			g.assign(n, fieldExpr, fieldType, fmt.Sprintf("make(%s)", g.typeString(fieldType)))
+			continue
+		}
+
+		if !isStructPtr(fieldType) && !isFuncOrChan(fieldType) {
+			return fmt.Errorf(
+				"found inject tag on unsupported field %s in type %s",
+				field.Name(),
+				n.typ,
+			)
+		}
+
+		_, isFunc := fieldType.Underlying().(*types.Signature)
+		if tag.Private && isFunc {
+			return fmt.Errorf(
+				"cannot use private inject on function field %s in type %s",
+				field.Name(),
+				n.typ,
+			)
+		}
+
+		if !tag.Private {
+			existing, err := g.findUnnamed(fieldType)
+			if err != nil {
+				return fmt.Errorf("%s for field %s in type %s", err, field.Name(), n.typ)
+			}
+			if existing != nil {
+				g.assign(n, fieldExpr, fieldType, existing.expr)
+				continue
+			}
+		}
+
+		if isFunc {
+			return fmt.Errorf(
+				"found no provided function for field %s in type %s",
+				field.Name(),
+				n.typ,
+			)
+		}
+
+		created := g.create(fieldType, tag.Private)
+		g.assign(n, fieldExpr, fieldType, created.expr)
+	}
+	return nil
+}
+
+// create declares a new instance of the struct pointer or channel type.
+func (g *generator) create(t types.Type, private bool) *node {
+	created := &node{
+		expr:    fmt.Sprintf("c%d", g.nextNode),
+		typ:     t,
+		private: private,
+		created: true,
+	}
+	g.nextNode++
+	if _, ok := t.Underlying().(*types.Chan); ok {
+		g.decls = append(g.decls, fmt.Sprintf("%s := make(%s)", created.expr, g.typeString(t)))
+	} else {
+		g.decls = append(g.decls, fmt.Sprintf(
+			"%s := new(%s)",
+			created.expr,
+			g.typeString(t.(*types.Pointer).Elem()),
+		))
+	}
+	g.unnamed = append(g.unnamed, created)
+	return created
+}
+
+// populateUnnamedInterface mirrors Graph.populateUnnamedInterface.
+func (g *generator) populateUnnamedInterface(n *node) error {
+	st := n.structType()
+	if st == nil || (n.name != "" && !isStructPtr(n.typ)) {
+		return nil
+	}
+
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		fieldType := field.Type()
+		tag, err := injecttag.Parse(st.Tag(i))
+		if err != nil || tag == nil || tag.Name != "" {
+			continue
+		}
+		if _, ok := fieldType.Underlying().(*types.Interface); !ok {
+			continue
+		}
+
+		if tag.Private {
+			return fmt.Errorf(
+				"found private inject tag on interface field %s in type %s",
+				field.Name(),
+				n.typ,
+			)
+		}
+
+		var found *node
+		for _, existing := range g.unnamed {
+			if existing.private || !types.AssignableTo(existing.typ, fieldType) {
+				continue
+			}
+			if embedsInjected(existing.typ, fieldType) {
+				continue
+			}
+			if found != nil {
+				return fmt.Errorf(
+					"found two assignable values for field %s in type %s. one type "+
+						"%s and another type %s",
+					field.Name(),
+					n.typ,
+					found.typ,
+					existing.typ,
+				)
+			}
+			found = existing
+		}
+		if found == nil {
+			return fmt.Errorf(
+				"found no assignable value for field %s in type %s",
+				field.Name(),
+				n.typ,
+			)
+		}
+		g.assign(n, n.expr+"."+field.Name(), fieldType, found.expr)
+	}
+	return nil
+}
+
+// findUnnamed returns the only unnamed node assignable to t, if any. Values of
+// different named func and chan types may be assignable to the same type.
+func (g *generator) findUnnamed(t types.Type) (*node, error) {
+	var found *node
+	for _, existing := range g.unnamed {
+		if existing.private || !types.AssignableTo(existing.typ, t) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf(
+				"found two assignable values %s and %s",
+				found.typ,
+				existing.typ,
+			)
+		}
+		found = existing
+	}
+	return found, nil
+}
+
+// assign emits an assignment, guarding it if the field may already have been
+// set by a provider.
+func (g *generator) assign(n *node, fieldExpr string, fieldType types.Type, value string) {
+	stmt := fmt.Sprintf("%s = %s", fieldExpr, value)
+	if n.guarded && isNillable(fieldType) {
+		stmt = fmt.Sprintf("if %s == nil {\n%s\n}", fieldExpr, stmt)
+	}
+	g.stmts = append(g.stmts, stmt)
+}
+
+func (g *generator) code(opts Options) ([]byte, error) {
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "// %s builds the %s object graph with the same wiring\n", opts.Func, opts.Root)
+	fmt.Fprintf(&body, "// inject.Graph.Populate would produce.\n")
+	fmt.Fprintf(&body, "func %s() *%s {\n", opts.Func, opts.Root)
+	for _, d := range g.decls {
+		fmt.Fprintln(&body, d)
+	}
+	for _, s := range g.stmts {
+		fmt.Fprintln(&body, s)
+	}
+	fmt.Fprintln(&body, "return root")
+	fmt.Fprintln(&body, "}")
+	var imports []string
+	for path, name := range g.imports {
+		if name == pathBase(path) {
+			imports = append(imports, strconv.Quote(path))
+		} else {
+			imports = append(imports, name+" "+strconv.Quote(path))
+		}
+	}
+	return g.file(imports, body.Bytes())
+}
+
+func (g *generator) test(opts Options) ([]byte, error) {
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "func Test%sMatchesPopulate(t *testing.T) {\n", opts.Func)
+	if opts.Unexported {
+		fmt.Fprintln(&body, "g := inject.Graph{Unexported: true}")
+	} else {
+		fmt.Fprintln(&body, "var g inject.Graph")
+	}
+	fmt.Fprintf(&body, "root := new(%s)\n", opts.Root)
+	fmt.Fprintln(&body, "err := g.Provide(")
+	fmt.Fprintln(&body, "&inject.Object{Value: root},")
+	for _, n := range g.nodes {
+		if n.provider == "" {
+			continue
+		}
+		if n.name == "" {
+			fmt.Fprintf(&body, "&inject.Object{Value: %s()},\n", n.provider)
+		} else {
+			fmt.Fprintf(&body, "&inject.Object{Value: %s(), Name: %s},\n", n.provider, strconv.Quote(n.name))
+		}
+	}
+	fmt.Fprintln(&body, ")")
+	fmt.Fprintln(&body, "if err != nil {\nt.Fatal(err)\n}")
+	fmt.Fprintln(&body, "if err := g.Populate(); err != nil {\nt.Fatal(err)\n}")
+	fmt.Fprintf(&body, "if err := injectgen.Compare(%s(), root); err != nil {\nt.Fatal(err)\n}\n", opts.Func)
+	fmt.Fprintln(&body, "}")
+	return g.file(
+		[]string{
+			strconv.Quote("testing"),
+			strconv.Quote("github.com/facebookgo/inject"),
+			strconv.Quote("github.com/facebookgo/inject/injectgen"),
+		},
+		body.Bytes(),
+	)
+}
+
+// file formats a complete source file with the given imports and body.
+func (g *generator) file(imports []string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "// Code generated by injectgen. DO NOT EDIT.")
+	fmt.Fprintln(&buf)
+	fmt.Fprintf(&buf, "package %s\n\n", g.pkg.Name())
+
+	// Standard library imports go first, in their own group.
+	var std, other []string
+	for _, imp := range imports {
+		path, _ := strconv.Unquote(imp[strings.Index(imp, `"`):])
+		if strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+			other = append(other, imp)
+		} else {
+			std = append(std, imp)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	if len(imports) != 0 {
+		fmt.Fprintln(&buf, "import (")
+		for _, imp := range std {
+			fmt.Fprintln(&buf, imp)
+		}
+		if len(std) != 0 && len(other) != 0 {
+			fmt.Fprintln(&buf)
+		}
+		for _, imp := range other {
+			fmt.Fprintln(&buf, imp)
+		}
+		fmt.Fprintln(&buf, ")")
+		fmt.Fprintln(&buf)
+	}
+	buf.Write(body)
+	return format.Source(buf.Bytes())
+}
+
+// typeString returns the type as it should be written in the generated code,
+// recording the necessary imports.
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		if name, ok := g.imports[p.Path()]; ok {
+			return name
+		}
+		name := p.Name()
+		for taken := true; taken; {
+			taken = false
+			for _, other := range g.imports {
+				if other == name {
+					taken = true
+					name += "_"
+					break
+				}
+			}
+		}
+		g.imports[p.Path()] = name
+		return name
+	})
+}
+
+func isStructPtr(t types.Type) bool {
+	p, ok := t.(*types.Pointer)
+	if !ok {
+		return false
+	}
+	_, ok = p.Elem().Underlying().(*types.Struct)
+	return ok
+}
+
+// embedsInjected mirrors the runtime check for objects that only implement an
+// interface by embedding it as an injected field.
+func embedsInjected(t types.Type, iface types.Type) bool {
+	if !isStructPtr(t) {
+		return false
+	}
+	st := t.(*types.Pointer).Elem().Underlying().(*types.Struct)
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if !f.Anonymous() || !types.IsInterface(f.Type()) {
+			continue
+		}
+		if !types.AssignableTo(f.Type(), iface) {
+			continue
+		}
+		if tag, err := injecttag.Parse(st.Tag(i)); err == nil && tag != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func isFuncOrChan(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Signature, *types.Chan:
+		return true
+	}
+	return false
+}
+
+func isNillable(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Interface, *types.Map, *types.Slice,
+		*types.Signature, *types.Chan:
+		return true
+	}
+	return false
+}
+
+func pathBase(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
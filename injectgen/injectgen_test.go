@@ -0,0 +1,111 @@
+package injectgen_test
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject/injectgen"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGenerate(t *testing.T) {
+	result, err := injectgen.Generate("./testdata/app", injectgen.Options{Root: "App"})
+	ensure.Nil(t, err)
+	golden(t, "inject_gen.go.golden", result.Code)
+	golden(t, "inject_gen_test.go.golden", result.Test)
+}
+
+// TestGeneratedMatchesPopulate builds the generated code along with a copy of
+// the app, and runs the generated test comparing it with Graph.Populate.
+func TestGeneratedMatchesPopulate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go test of the generated code in short mode")
+	}
+	gotool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	result, err := injectgen.Generate("./testdata/app", injectgen.Options{Root: "App"})
+	ensure.Nil(t, err)
+
+	// The copy has to be inside the module to import it.
+	dir, err := ioutil.TempDir("testdata", "gen")
+	ensure.Nil(t, err)
+	defer os.RemoveAll(dir)
+	app, err := ioutil.ReadFile(filepath.Join("testdata", "app", "app.go"))
+	ensure.Nil(t, err)
+	ensure.Nil(t, ioutil.WriteFile(filepath.Join(dir, "app.go"), app, 0644))
+	ensure.Nil(t, ioutil.WriteFile(filepath.Join(dir, "inject_gen.go"), result.Code, 0644))
+	ensure.Nil(t, ioutil.WriteFile(filepath.Join(dir, "inject_gen_test.go"), result.Test, 0644))
+
+	cmd := exec.Command(gotool, "test", "-run", "TestInjectAppMatchesPopulate", "./"+filepath.ToSlash(dir))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("generated test failed: %s\n%s", err, out)
+	}
+}
+
+func golden(t *testing.T, name string, actual []byte) {
+	path := filepath.Join("testdata", "app", name)
+	if *update {
+		ensure.Nil(t, ioutil.WriteFile(path, actual, 0644))
+	}
+	expected, err := ioutil.ReadFile(path)
+	ensure.Nil(t, err)
+	if !bytes.Equal(expected, actual) {
+		t.Fatalf("%s does not match, expected:\n%s\nactual:\n%s", name, expected, actual)
+	}
+}
+
+func TestGenerateMissingRoot(t *testing.T) {
+	_, err := injectgen.Generate("./testdata/app", injectgen.Options{Root: "Missing"})
+	ensure.NotNil(t, err)
+	ensure.StringContains(t, err.Error(), "root type Missing not found")
+}
+
+type compareAnswer struct {
+	N int
+}
+
+type compareRoot struct {
+	A *compareAnswer
+	B *compareAnswer
+	M map[string]int
+}
+
+func TestCompare(t *testing.T) {
+	shared := &compareAnswer{}
+	a := &compareRoot{A: shared, B: shared, M: map[string]int{}}
+	b := &compareRoot{A: &compareAnswer{}, B: &compareAnswer{}, M: map[string]int{}}
+	c := &compareRoot{A: &compareAnswer{}, B: &compareAnswer{N: 1}}
+	d := &compareRoot{M: map[string]int{}}
+	other := &compareAnswer{}
+	d.A, d.B = other, other
+
+	ensure.Nil(t, injectgen.Compare(a, d))
+	ensure.DeepEqual(t, injectgen.Compare(a, b).Error(), "B: wired to different objects")
+	ensure.DeepEqual(t, injectgen.Compare(b, c).Error(), "B.N: values are not equal")
+	ensure.DeepEqual(t, injectgen.Compare(a, a.A).Error(),
+		"root: type *injectgen_test.compareRoot does not match *injectgen_test.compareAnswer")
+}
+
+func TestGenerateUnexported(t *testing.T) {
+	_, err := injectgen.Generate("./testdata/unexported", injectgen.Options{Root: "App"})
+	ensure.Err(t, err, regexp.MustCompile("inject requested on unexported field answer"))
+
+	result, err := injectgen.Generate("./testdata/unexported", injectgen.Options{
+		Root:       "App",
+		Unexported: true,
+	})
+	ensure.Nil(t, err)
+	ensure.StringContains(t, string(result.Code), "root.answer = c0")
+	ensure.StringContains(t, string(result.Test), "inject.Graph{Unexported: true}")
+}
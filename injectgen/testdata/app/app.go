@@ -0,0 +1,73 @@
+package app
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type Answerable interface {
+	Answer() int
+}
+
+type Answer struct{}
+
+func (*Answer) Answer() int { return 42 }
+
+type Config struct {
+	Answer *Answer `inject:""`
+}
+
+type Client struct {
+	Transport http.RoundTripper `inject:""`
+	Config    *Config           `inject:"config"`
+}
+
+type Base struct {
+	Answer *Answer `inject:""`
+}
+
+type Handler struct {
+	*Base      `inject:"inline"`
+	Answerable `inject:""`
+}
+
+type Clock func() time.Time
+
+type Events chan string
+
+type App struct {
+	Clock      Clock             `inject:""`
+	Events     Events            `inject:""`
+	Private    Events            `inject:"private"`
+	Handler    *Handler          `inject:""`
+	Client     *Client           `inject:""`
+	Copy       *Client           `inject:"private"`
+	Answerable Answerable        `inject:""`
+	Cache      map[string]string `inject:"private"`
+	Base       *url.URL          `inject:"base"`
+	Inline     struct {
+		Client *Client `inject:""`
+	} `inject:"inline"`
+	Ignored *Client
+}
+
+//inject:provide
+func newTransport() *http.Transport {
+	return &http.Transport{}
+}
+
+//inject:provide
+func newClock() Clock {
+	return time.Now
+}
+
+//inject:provide config
+func newConfig() *Config {
+	return &Config{}
+}
+
+//inject:provide base
+func newBase() *url.URL {
+	return &url.URL{Scheme: "https", Host: "example.com"}
+}
@@ -0,0 +1,10 @@
+package unexported
+
+import "net/url"
+
+type answer struct{}
+
+type App struct {
+	answer *answer  `inject:""`
+	base   *url.URL `inject:"private"`
+}
@@ -0,0 +1,170 @@
+// Package injecthttp serves a read only view of a populated inject.Graph over
+// HTTP, in the spirit of net/http/pprof. It is meant to be mounted on a debug
+// server:
+//
+//	http.Handle("/debug/inject/", injecthttp.Handler(&graph))
+//
+// The root lists the objects in the order they were provided, along with their
+// flags and injected fields. Adding ?format=json returns the same as JSON. The
+// dot path returns the graph in the DOT language, to be rendered using
+// Graphviz, as in:
+//
+//	curl localhost:8080/debug/inject/dot | dot -Tsvg > graph.svg
+package injecthttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/facebookgo/inject"
+)
+
+// An Object as served.
+type Object struct {
+	ID        int      `json:"id"`
+	Type      string   `json:"type"`
+	Name      string   `json:"name,omitempty"`
+	Qualifier string   `json:"qualifier,omitempty"` // The type of the qualifier.
+	Flags     []string `json:"flags,omitempty"`
+	Fields    []*Field `json:"fields,omitempty"`
+}
+
+// A Field injected with a dependency.
+type Field struct {
+	Name       string `json:"name"`
+	Resolution string `json:"resolution,omitempty"`
+	Dep        int    `json:"dep"` // The ID of the dependency, or -1 if it is not listed.
+	DepString  string `json:"dep_string"`
+}
+
+// Handler returns an http.Handler serving the Graph. It may be served while
+// lazy fields are resolved, and a frozen Graph is served from its snapshot.
+func Handler(g *inject.Graph) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		objects := Objects(g)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/dot"):
+			w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+			writeDOT(w, objects)
+		case r.URL.Query().Get("format") == "json":
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(objects); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		default:
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			writeText(w, objects)
+		}
+	})
+}
+
+// Objects describes the objects of the Graph in the order they were provided.
+func Objects(g *inject.Graph) []*Object {
+	sorted := g.SortedObjects()
+	ids := make(map[*inject.Object]int, len(sorted))
+	for i, o := range sorted {
+		ids[o] = i
+	}
+
+	objects := make([]*Object, 0, len(sorted))
+	for i, o := range sorted {
+		object := &Object{
+			ID:    i,
+			Type:  fmt.Sprint(reflect.TypeOf(o.Value)),
+			Name:  o.Name,
+			Flags: flags(o),
+		}
+		if o.Qualifier != nil {
+			object.Qualifier = reflect.TypeOf(o.Qualifier).String()
+		}
+		for _, r := range g.Resolutions(o) {
+			// Inline objects are part of the object, and maps, preset and
+			// unresolved lazy fields have no dependency.
+			if r.Object == nil || r.Kind == inject.ResolvedInline {
+				continue
+			}
+			field := &Field{
+				Name:       r.Field,
+				Resolution: r.Kind.String(),
+				Dep:        -1,
+				DepString:  r.Object.String(),
+			}
+			if id, ok := ids[r.Object]; ok {
+				field.Dep = id
+			}
+			object.Fields = append(object.Fields, field)
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+func flags(o *inject.Object) []string {
+	var flags []string
+	if o.Complete {
+		flags = append(flags, "complete")
+	}
+	if o.Root {
+		flags = append(flags, "root")
+	}
+	if o.Created() {
+		flags = append(flags, "created")
+	}
+	if o.Private() {
+		flags = append(flags, "private")
+	}
+	return flags
+}
+
+func writeText(w io.Writer, objects []*Object) {
+	for _, o := range objects {
+		fmt.Fprintf(w, "%d %s", o.ID, o.Type)
+		if o.Name != "" {
+			fmt.Fprintf(w, " named %s", o.Name)
+		}
+		if o.Qualifier != "" {
+			fmt.Fprintf(w, " qualified by %s", o.Qualifier)
+		}
+		if len(o.Flags) != 0 {
+			fmt.Fprintf(w, " [%s]", strings.Join(o.Flags, " "))
+		}
+		fmt.Fprintln(w)
+		for _, f := range o.Fields {
+			fmt.Fprintf(w, "\t%s -> ", f.Name)
+			if f.Dep != -1 {
+				fmt.Fprintf(w, "%d ", f.Dep)
+			}
+			fmt.Fprint(w, f.DepString)
+			if f.Resolution != "" {
+				fmt.Fprintf(w, " (%s)", f.Resolution)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+func writeDOT(w io.Writer, objects []*Object) {
+	fmt.Fprintln(w, "digraph inject {")
+	for _, o := range objects {
+		label := o.Type
+		if o.Name != "" {
+			label += "\nnamed " + o.Name
+		}
+		if o.Qualifier != "" {
+			label += "\nqualified by " + o.Qualifier
+		}
+		fmt.Fprintf(w, "\tn%d [label=%q];\n", o.ID, label)
+	}
+	for _, o := range objects {
+		for _, f := range o.Fields {
+			if f.Dep != -1 {
+				fmt.Fprintf(w, "\tn%d -> n%d [label=%q];\n", o.ID, f.Dep, f.Name)
+			}
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
@@ -0,0 +1,135 @@
+package injecthttp_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+	"github.com/facebookgo/inject/injecthttp"
+)
+
+type Store struct{}
+
+type Cache struct{}
+
+type Server struct {
+	Store *Store `inject:""`
+	Cache *Cache `inject:"private"`
+	Named *Store `inject:"backup"`
+}
+
+func newGraph(t *testing.T) *inject.Graph {
+	var g inject.Graph
+	ensure.Nil(t, g.Provide(
+		&inject.Object{Value: &Server{}, Root: true},
+		&inject.Object{Value: &Store{}, Name: "backup"},
+	))
+	ensure.Nil(t, g.Populate())
+	return &g
+}
+
+func get(t *testing.T, g *inject.Graph, target string) string {
+	w := httptest.NewRecorder()
+	injecthttp.Handler(g).ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+	ensure.DeepEqual(t, w.Code, 200)
+	body, err := io.ReadAll(w.Body)
+	ensure.Nil(t, err)
+	return string(body)
+}
+
+func TestText(t *testing.T) {
+	ensure.DeepEqual(t, get(t, newGraph(t), "/debug/inject/"), ""+
+		"0 *injecthttp_test.Server [root]\n"+
+		"\tCache -> 3 *injecthttp_test.Cache (created)\n"+
+		"\tNamed -> 1 *injecthttp_test.Store named backup (named)\n"+
+		"\tStore -> 2 *injecthttp_test.Store (created)\n"+
+		"1 *injecthttp_test.Store named backup\n"+
+		"2 *injecthttp_test.Store [created]\n"+
+		"3 *injecthttp_test.Cache [created private]\n")
+}
+
+func TestJSON(t *testing.T) {
+	var objects []*injecthttp.Object
+	body := get(t, newGraph(t), "/debug/inject/?format=json")
+	ensure.Nil(t, json.Unmarshal([]byte(body), &objects))
+	ensure.DeepEqual(t, len(objects), 4)
+	ensure.DeepEqual(t, objects[1].Name, "backup")
+	ensure.DeepEqual(t, objects[0].Fields[1], &injecthttp.Field{
+		Name:       "Named",
+		Resolution: "named",
+		Dep:        1,
+		DepString:  "*injecthttp_test.Store named backup",
+	})
+}
+
+func TestDOT(t *testing.T) {
+	ensure.DeepEqual(t, get(t, newGraph(t), "/debug/inject/dot"), ""+
+		"digraph inject {\n"+
+		"\tn0 [label=\"*injecthttp_test.Server\"];\n"+
+		"\tn1 [label=\"*injecthttp_test.Store\\nnamed backup\"];\n"+
+		"\tn2 [label=\"*injecthttp_test.Store\"];\n"+
+		"\tn3 [label=\"*injecthttp_test.Cache\"];\n"+
+		"\tn0 -> n3 [label=\"Cache\"];\n"+
+		"\tn0 -> n1 [label=\"Named\"];\n"+
+		"\tn0 -> n2 [label=\"Store\"];\n"+
+		"}\n")
+}
+
+type Primary struct{}
+
+func TestQualifier(t *testing.T) {
+	var g inject.Graph
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &Store{}, Qualifier: Primary{}}))
+	ensure.Nil(t, g.Populate())
+	ensure.DeepEqual(t, get(t, &g, "/debug/inject/"),
+		"0 *injecthttp_test.Store qualified by injecthttp_test.Primary\n")
+	ensure.DeepEqual(t, get(t, &g, "/debug/inject/dot"), ""+
+		"digraph inject {\n"+
+		"\tn0 [label=\"*injecthttp_test.Store\\nqualified by injecthttp_test.Primary\"];\n"+
+		"}\n")
+}
+
+type LazyServer struct {
+	Store func() (*Store, error) `inject:",lazy"`
+}
+
+func TestFrozenWhileResolvingLazily(t *testing.T) {
+	g := inject.Graph{FreezeOnPopulate: true}
+	var v LazyServer
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := v.Store()
+		ensure.Nil(t, err)
+	}()
+	ensure.DeepEqual(t, get(t, &g, "/debug/inject/"), "0 *injecthttp_test.LazyServer\n")
+	<-done
+}
+
+func TestWhileResolvingLazily(t *testing.T) {
+	var g inject.Graph
+	var v LazyServer
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := v.Store()
+		ensure.Nil(t, err)
+	}()
+	for i := 0; i < 10; i++ {
+		get(t, &g, "/debug/inject/")
+	}
+	<-done
+	ensure.DeepEqual(t, get(t, &g, "/debug/inject/"), ""+
+		"0 *injecthttp_test.LazyServer\n"+
+		"\tStore -> 1 *injecthttp_test.Store (lazy)\n"+
+		"1 *injecthttp_test.Store [created]\n")
+}
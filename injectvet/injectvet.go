@@ -0,0 +1,137 @@
+// Package injectvet provides an analyzer that reports invalid inject struct
+// tags at build time. It flags the same problems Graph.Populate would report
+// at runtime, using the same tag parser.
+package injectvet
+
+import (
+	"go/ast"
+	"go/types"
+
+	"github.com/facebookgo/inject/internal/injecttag"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Analyzer reports invalid inject struct tags.
+var Analyzer = &analysis.Analyzer{
+	Name:     "injectvet",
+	Doc:      "check inject struct tags for problems reported by Graph.Populate",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// unexported allows tags on unexported fields, for code populated by a Graph
+// with Unexported set.
+var unexported bool
+
+func init() {
+	Analyzer.Flags.BoolVar(&unexported, "unexported", false,
+		"allow inject tags on unexported fields")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	in := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	in.Preorder([]ast.Node{(*ast.StructType)(nil)}, func(n ast.Node) {
+		st, ok := pass.TypesInfo.TypeOf(n.(*ast.StructType)).(*types.Struct)
+		if !ok {
+			return
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			checkField(pass, st.Field(i), st.Tag(i))
+		}
+	})
+	return nil, nil
+}
+
+// checkField mirrors the checks made by Graph.populateExplicit and
+// Graph.populateUnnamedInterface.
+func checkField(pass *analysis.Pass, field *types.Var, fieldTag string) {
+	tag, err := injecttag.Parse(fieldTag)
+	if err != nil {
+		pass.Reportf(field.Pos(), "unexpected tag format `%s` for field %s", fieldTag, field.Name())
+		return
+	}
+
+	// Skip fields without a tag.
+	if tag == nil {
+		return
+	}
+
+	if !field.Exported() && !unexported {
+		pass.Reportf(field.Pos(), "inject requested on unexported field %s", field.Name())
+		return
+	}
+
+	underlying := field.Type().Underlying()
+	_, isStruct := underlying.(*types.Struct)
+	embeddedPtr := field.Anonymous() && isStructPtr(field.Type())
+	if tag.Inline && !isStruct && !embeddedPtr {
+		pass.Reportf(field.Pos(), "inline requested on non inlined field %s", field.Name())
+		return
+	}
+
+	if tag.Lazy {
+		if !isLazyFunc(field.Type()) {
+			pass.Reportf(field.Pos(), "lazy inject on field %s must be of type func() (T, error)", field.Name())
+		}
+		return
+	}
+
+	if tag.Intercept != "" {
+		if _, ok := underlying.(*types.Interface); !ok {
+			pass.Reportf(field.Pos(), "cannot intercept non interface field %s", field.Name())
+			return
+		}
+	}
+
+	// Named and qualified injects can be of any type.
+	if tag.Name != "" || tag.Qualifier != "" {
+		return
+	}
+
+	switch t := underlying.(type) {
+	case *types.Struct:
+		if tag.Private {
+			pass.Reportf(field.Pos(), "cannot use private inject on inline struct on field %s", field.Name())
+		} else if !tag.Inline {
+			pass.Reportf(field.Pos(), "inline struct on field %s requires an explicit \"inline\" tag", field.Name())
+		}
+	case *types.Interface:
+		if tag.Private {
+			pass.Reportf(field.Pos(), "found private inject tag on interface field %s", field.Name())
+		}
+	case *types.Signature:
+		if tag.Private {
+			pass.Reportf(field.Pos(), "cannot use private inject on function field %s", field.Name())
+		}
+	case *types.Chan:
+	case *types.Map:
+		if !tag.Private {
+			pass.Reportf(field.Pos(), "inject on map field %s must be named or private", field.Name())
+		}
+	case *types.Pointer:
+		if !isStructPtr(t) {
+			pass.Reportf(field.Pos(), "found inject tag on unsupported field %s", field.Name())
+		}
+	default:
+		pass.Reportf(field.Pos(), "found inject tag on unsupported field %s", field.Name())
+	}
+}
+
+func isLazyFunc(t types.Type) bool {
+	sig, ok := t.Underlying().(*types.Signature)
+	if !ok || sig.Params().Len() != 0 || sig.Results().Len() != 2 {
+		return false
+	}
+	return types.Identical(sig.Results().At(1).Type(), types.Universe.Lookup("error").Type())
+}
+
+func isStructPtr(t types.Type) bool {
+	p, ok := t.Underlying().(*types.Pointer)
+	if !ok {
+		return false
+	}
+	_, ok = p.Elem().Underlying().(*types.Struct)
+	return ok
+}
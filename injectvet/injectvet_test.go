@@ -0,0 +1,19 @@
+package injectvet_test
+
+import (
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject/injectvet"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), injectvet.Analyzer, "a")
+}
+
+func TestAnalyzerUnexported(t *testing.T) {
+	ensure.Nil(t, injectvet.Analyzer.Flags.Set("unexported", "true"))
+	defer injectvet.Analyzer.Flags.Set("unexported", "false")
+	analysistest.Run(t, analysistest.TestData(), injectvet.Analyzer, "unexported")
+}
@@ -0,0 +1,50 @@
+package a
+
+type Answerable interface {
+	Answer() int
+}
+
+type Answer struct{}
+
+type Valid struct {
+	A *Answer                    `inject:""`
+	B *Answer                    `inject:"private"`
+	C Answerable                 `inject:""`
+	D map[string]int             `inject:"private"`
+	E int                        `inject:"named int"`
+	F map[string]int             `inject:"named map"`
+	G struct{}                   `inject:"inline"`
+	H *Answer                    `json:"h"`
+	I Answer                     `inject:"inline"`
+	J func() Answerable          `inject:"named func"`
+	K func() int                 `inject:""`
+	L chan int                   `inject:""`
+	M chan int                   `inject:"private"`
+	N func() (*Answer, error)    `inject:",lazy"`
+	O func() (Answerable, error) `inject:"named lazy,lazy"`
+	P Answerable                 `inject:",intercept=audit"`
+}
+
+type Embedded struct {
+	*Answer    `inject:"inline"`
+	Answerable `inject:""`
+}
+
+type Invalid struct {
+	a *Answer        `inject:""`        // want "inject requested on unexported field a"
+	B *Answer        `inject:"inline"`  // want "inline requested on non inlined field B"
+	C Answerable     `inject:"private"` // want "found private inject tag on interface field C"
+	D map[string]int `inject:""`        // want "inject on map field D must be named or private"
+	E Answer         `inject:""`        // want "inline struct on field E requires an explicit \"inline\" tag"
+	F Answer         `inject:"private"` // want "cannot use private inject on inline struct on field F"
+	G int            `inject:""`        // want "found inject tag on unsupported field G"
+	H *int           `inject:""`        // want "found inject tag on unsupported field H"
+	I *Answer        `inject:"`         // want "unexpected tag format `inject:\"` for field I"
+	J func() int     `inject:"private"` // want "cannot use private inject on function field J"
+	K func() *Answer `inject:",lazy"`   // want "lazy inject on field K must be of type func\\(\\) \\(T, error\\)"
+	L *Answer        `inject:",eager"`  // want "unexpected tag format `inject:\",eager\"` for field L"
+}
+
+type InvalidIntercept struct {
+	A *Answer `inject:",intercept=audit"` // want "cannot intercept non interface field A"
+}
@@ -0,0 +1,11 @@
+package unexported
+
+type Answer struct{}
+
+type Valid struct {
+	a *Answer `inject:""`
+}
+
+type Invalid struct {
+	a int `inject:""` // want "found inject tag on unsupported field a"
+}
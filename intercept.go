@@ -0,0 +1,158 @@
+package inject
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/facebookgo/inject/internal/injecttag"
+)
+
+// A Handler handles the method calls made on a proxy. For variadic methods the
+// last argument is the slice of variadic arguments.
+type Handler func(method string, args []reflect.Value) []reflect.Value
+
+// An Interceptor is called for method calls on the interface values injected
+// into the fields it selects. It selects fields of the Interface type if set,
+// and fields with the intercept=Name tag option if Name is set. Interceptors
+// are called in order, each calling Proceed to continue the Call.
+type Interceptor struct {
+	Name      string
+	Interface reflect.Type
+	Intercept func(c *Call) []reflect.Value
+}
+
+// A Call of a method on an intercepted interface value.
+type Call struct {
+	Object *Object // The Object being called.
+	Method string
+	Args   []reflect.Value
+
+	target       reflect.Value
+	interceptors []*Interceptor
+	next         int
+}
+
+// Proceed calls the next Interceptor, or the method of the Object if there
+// are no more Interceptors.
+func (c *Call) Proceed() []reflect.Value {
+	if c.next < len(c.interceptors) {
+		i := c.interceptors[c.next]
+		c.next++
+		return i.Intercept(c)
+	}
+	m := c.target.MethodByName(c.Method)
+	if m.Type().IsVariadic() {
+		return m.CallSlice(c.Args)
+	}
+	return m.Call(c.Args)
+}
+
+// interception identifies the proxy of an Object for a type and an
+// interceptor name.
+type interception struct {
+	object *Object
+	typ    reflect.Type
+	name   string
+}
+
+var handlerType = reflect.TypeOf(Handler(nil))
+
+// loadInterceptors checks the Interceptors and Proxies and indexes the Proxies
+// by the interface type they make values of.
+func (g *Graph) loadInterceptors() error {
+	g.proxies = nil
+	for _, p := range g.Proxies {
+		fn := reflect.ValueOf(p)
+		t := fn.Type()
+		if t.Kind() != reflect.Func || t.NumIn() != 1 || t.In(0) != handlerType ||
+			t.NumOut() != 1 || t.Out(0).Kind() != reflect.Interface {
+			return fmt.Errorf(
+				"expected proxy to be a func(inject.Handler) T for an interface type T but got type %s",
+				t,
+			)
+		}
+		if g.proxies == nil {
+			g.proxies = make(map[reflect.Type]reflect.Value)
+		}
+		if _, ok := g.proxies[t.Out(0)]; ok {
+			return fmt.Errorf("provided two proxies for interface %s", t.Out(0))
+		}
+		g.proxies[t.Out(0)] = fn
+	}
+
+	for _, i := range g.Interceptors {
+		if i.Name == "" && i.Interface == nil {
+			return fmt.Errorf("interceptor selects neither a name nor an interface")
+		}
+		if i.Interface != nil {
+			if i.Interface.Kind() != reflect.Interface {
+				return fmt.Errorf("cannot intercept non interface type %s", i.Interface)
+			}
+			if _, ok := g.proxies[i.Interface]; !ok {
+				return fmt.Errorf("found no proxy for interface %s", i.Interface)
+			}
+		}
+	}
+	return nil
+}
+
+// checkIntercept checks the named interceptor can be used for the type.
+func (g *Graph) checkIntercept(t reflect.Type, name string) error {
+	if t.Kind() != reflect.Interface {
+		return fmt.Errorf("type %s is not an interface", t)
+	}
+	if _, ok := g.proxies[t]; !ok {
+		return fmt.Errorf("found no proxy for interface %s", t)
+	}
+	for _, i := range g.Interceptors {
+		if i.Name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("found no interceptor named %s", name)
+}
+
+// fieldValue returns the value of the Object to assign to a field of the given
+// type and tag. It is decorated, and stands in for a proxy if the field is
+// intercepted. The Object is still the dependency recorded for the field.
+func (g *Graph) fieldValue(o *Object, t reflect.Type, tag *injecttag.Tag) reflect.Value {
+	v := g.decorated(o, t)
+	if t.Kind() != reflect.Interface {
+		return v
+	}
+
+	var interceptors []*Interceptor
+	for _, i := range g.Interceptors {
+		if i.Interface == t || (i.Name != "" && i.Name == tag.Intercept) {
+			interceptors = append(interceptors, i)
+		}
+	}
+	if len(interceptors) == 0 {
+		return v
+	}
+
+	key := interception{object: o, typ: t, name: tag.Intercept}
+	if p, ok := g.proxied[key]; ok {
+		return p
+	}
+
+	handler := Handler(func(method string, args []reflect.Value) []reflect.Value {
+		c := &Call{
+			Object:       o,
+			Method:       method,
+			Args:         args,
+			target:       v,
+			interceptors: interceptors,
+		}
+		return c.Proceed()
+	})
+	p := g.proxies[t].Call([]reflect.Value{reflect.ValueOf(handler)})[0]
+	if g.proxied == nil {
+		g.proxied = make(map[interception]reflect.Value)
+	}
+	g.proxied[key] = p
+	if g.Logger != nil {
+		g.Logger.Debugf("intercepted %s as %s", o, t)
+	}
+	return p
+}
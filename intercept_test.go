@@ -0,0 +1,119 @@
+package inject_test
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+)
+
+var answerableType = reflect.TypeOf((*Answerable)(nil)).Elem()
+
+type answerableProxy struct {
+	handler inject.Handler
+}
+
+func (p *answerableProxy) Answer() int {
+	return p.handler("Answer", nil)[0].Interface().(int)
+}
+
+func newAnswerableProxy(h inject.Handler) Answerable {
+	return &answerableProxy{handler: h}
+}
+
+type TypeForIntercept struct{}
+
+func (*TypeForIntercept) Answer() int {
+	return 42
+}
+
+func recordingInterceptor(name string, calls *[]string) func(*inject.Call) []reflect.Value {
+	return func(c *inject.Call) []reflect.Value {
+		*calls = append(*calls, name+" "+c.Method)
+		return c.Proceed()
+	}
+}
+
+func TestInterceptByInterface(t *testing.T) {
+	var calls []string
+	g := inject.Graph{
+		Proxies: []interface{}{newAnswerableProxy},
+		Interceptors: []*inject.Interceptor{
+			{Interface: answerableType, Intercept: recordingInterceptor("first", &calls)},
+			{Interface: answerableType, Intercept: recordingInterceptor("second", &calls)},
+		},
+	}
+	var v struct {
+		A Answerable `inject:""`
+	}
+	target := &TypeForIntercept{}
+	o := &inject.Object{Value: &v}
+	ensure.Nil(t, g.Provide(o, &inject.Object{Value: target}))
+	ensure.Nil(t, g.Populate())
+
+	_, ok := v.A.(*answerableProxy)
+	ensure.True(t, ok)
+	ensure.True(t, o.Fields["A"].Value == target)
+	ensure.DeepEqual(t, v.A.Answer(), 42)
+	ensure.DeepEqual(t, calls, []string{"first Answer", "second Answer"})
+}
+
+func TestInterceptByName(t *testing.T) {
+	var calls []string
+	g := inject.Graph{
+		Proxies: []interface{}{newAnswerableProxy},
+		Interceptors: []*inject.Interceptor{
+			{Name: "audit", Intercept: recordingInterceptor("audit", &calls)},
+		},
+	}
+	var v struct {
+		A Answerable `inject:",intercept=audit"`
+		B Answerable `inject:""`
+	}
+	target := &TypeForIntercept{}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}, &inject.Object{Value: target}))
+	ensure.Nil(t, g.Populate())
+
+	ensure.True(t, v.B == target)
+	ensure.DeepEqual(t, v.A.Answer(), 42)
+	ensure.DeepEqual(t, v.B.Answer(), 42)
+	ensure.DeepEqual(t, calls, []string{"audit Answer"})
+}
+
+func TestInterceptWithoutProxy(t *testing.T) {
+	g := inject.Graph{
+		Interceptors: []*inject.Interceptor{{Interface: answerableType}},
+	}
+	ensure.Err(t, g.Populate(), regexp.MustCompile(
+		"^found no proxy for interface inject_test.Answerable$"))
+}
+
+func TestInterceptUnknownName(t *testing.T) {
+	g := inject.Graph{Proxies: []interface{}{newAnswerableProxy}}
+	var v struct {
+		A Answerable `inject:",intercept=audit"`
+	}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}, &inject.Object{Value: &TypeForIntercept{}}))
+	ensure.Err(t, g.Populate(), regexp.MustCompile(
+		`^cannot intercept field A in type \*struct { A inject_test.Answerable "inject:\\",intercept=audit\\"" }: found no interceptor named audit$`))
+}
+
+func TestInterceptNonInterface(t *testing.T) {
+	g := inject.Graph{
+		Interceptors: []*inject.Interceptor{{Name: "audit"}},
+	}
+	var v struct {
+		A *TypeForIntercept `inject:",intercept=audit"`
+	}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Err(t, g.Populate(), regexp.MustCompile(
+		`^cannot intercept field A in type .*: type \*inject_test.TypeForIntercept is not an interface$`))
+}
+
+func TestInvalidProxy(t *testing.T) {
+	g := inject.Graph{Proxies: []interface{}{func() Answerable { return nil }}}
+	ensure.Err(t, g.Populate(), regexp.MustCompile(
+		`^expected proxy to be a func\(inject.Handler\) T for an interface type T but got type func\(\) inject_test.Answerable$`))
+}
@@ -0,0 +1,112 @@
+// Package injecttag parses the inject struct tag. It is shared by the runtime
+// and the static checks so the two never disagree about the tag format.
+//
+// The tag value is made of a name or one of the "private" and "inline"
+// keywords or a qualifier, optionally followed by comma separated options.
+package injecttag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/facebookgo/structtag"
+)
+
+// Tag is a parsed inject struct tag.
+type Tag struct {
+	Name      string
+	Qualifier string // The name of the qualifier type, as in "qualifier=pkg.Primary".
+	Inline    bool
+	Private   bool
+	Lazy      bool
+	Intercept string // The name of the interceptor, as in "intercept=audit".
+	Optional  bool
+}
+
+const (
+	qualifierPrefix = "qualifier="
+	interceptPrefix = "intercept="
+)
+
+var (
+	injectOnly    = &Tag{}
+	injectPrivate = &Tag{Private: true}
+	injectInline  = &Tag{Inline: true}
+)
+
+// Parse the inject tag from the given struct tag. It returns nil if there is
+// no inject tag.
+func Parse(t string) (*Tag, error) {
+	found, value, err := structtag.Extract("inject", t)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+	if value == "" {
+		return injectOnly, nil
+	}
+	if value == "inline" {
+		return injectInline, nil
+	}
+	if value == "private" {
+		return injectPrivate, nil
+	}
+
+	parts := strings.Split(value, ",")
+	tag := &Tag{}
+	switch {
+	case parts[0] == "inline":
+		tag.Inline = true
+	case parts[0] == "private":
+		tag.Private = true
+	case strings.HasPrefix(parts[0], qualifierPrefix):
+		tag.Qualifier = strings.TrimPrefix(parts[0], qualifierPrefix)
+		if tag.Qualifier == "" {
+			return nil, fmt.Errorf("empty inject qualifier")
+		}
+	default:
+		tag.Name = parts[0]
+	}
+	for _, option := range parts[1:] {
+		switch option {
+		case "lazy":
+			tag.Lazy = true
+		case "optional":
+			tag.Optional = true
+		default:
+			if strings.HasPrefix(option, interceptPrefix) && option != interceptPrefix {
+				tag.Intercept = strings.TrimPrefix(option, interceptPrefix)
+				continue
+			}
+			return nil, fmt.Errorf("unknown inject option %q", option)
+		}
+	}
+	return tag, nil
+}
+
+// Format the Tag as a struct tag that Parse would return it for.
+func (t *Tag) Format() string {
+	var parts []string
+	switch {
+	case t.Inline:
+		parts = append(parts, "inline")
+	case t.Private:
+		parts = append(parts, "private")
+	case t.Qualifier != "":
+		parts = append(parts, qualifierPrefix+t.Qualifier)
+	default:
+		parts = append(parts, t.Name)
+	}
+	if t.Lazy {
+		parts = append(parts, "lazy")
+	}
+	if t.Intercept != "" {
+		parts = append(parts, interceptPrefix+t.Intercept)
+	}
+	if t.Optional {
+		parts = append(parts, "optional")
+	}
+	return fmt.Sprintf("inject:%q", strings.Join(parts, ","))
+}
@@ -0,0 +1,125 @@
+package inject
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/facebookgo/inject/internal/injecttag"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// isLazyFunc checks if the type is a func() (T, error).
+func isLazyFunc(t reflect.Type) bool {
+	return t.Kind() == reflect.Func && t.NumIn() == 0 && t.NumOut() == 2 &&
+		t.Out(1) == errorType
+}
+
+// lazy returns a function of the given type which resolves the dependency
+// described by the tag the first time it is called. Failures are returned to
+// the caller and resolution is retried on the next call.
+func (g *Graph) lazy(o *Object, r *Resolution, t reflect.Type, tag *injecttag.Tag) reflect.Value {
+	var mu sync.Mutex
+	var value reflect.Value
+	return reflect.MakeFunc(t, func([]reflect.Value) []reflect.Value {
+		mu.Lock()
+		defer mu.Unlock()
+		if !value.IsValid() {
+			var err error
+			if value, err = g.resolveLazy(o, r, t.Out(0), tag); err != nil {
+				return []reflect.Value{reflect.Zero(t.Out(0)), reflect.ValueOf(&err).Elem()}
+			}
+		}
+		return []reflect.Value{value, reflect.Zero(errorType)}
+	})
+}
+
+// resolveLazy resolves a lazy dependency by populating a holder struct with a
+// single field of the required type, which ensures the same rules apply as for
+// eager dependencies.
+func (g *Graph) resolveLazy(o *Object, r *Resolution, t reflect.Type, tag *injecttag.Tag) (reflect.Value, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	eager := *tag
+	eager.Lazy = false
+	holderType := reflect.StructOf([]reflect.StructField{{
+		Name: "Value",
+		Type: t,
+		Tag:  reflect.StructTag(eager.Format()),
+	}})
+	holder := &Object{
+		Value:    reflect.New(holderType).Interface(),
+		private:  true,
+		embedded: true,
+		module:   o.module,
+	}
+
+	// Failures are retried on the next call, so the holder and the Objects
+	// created for it are forgotten to not grow the Graph with each attempt.
+	start := len(g.unnamed)
+	if err := g.provide(holder); err != nil {
+		g.forget(g.added(start))
+		return reflect.Value{}, err
+	}
+	if err := g.populateUnnamed(start); err != nil {
+		g.forget(g.added(start))
+		// Errors refer to the holder, which is an implementation detail.
+		msg := strings.Replace(
+			err.Error(),
+			fmt.Sprintf("field Value in type %s", holder.reflectType),
+			fmt.Sprintf("field %s in type %s", r.Field, o.reflectType),
+			-1,
+		)
+		return reflect.Value{}, errors.New(msg)
+	}
+
+	// Maps and channels made for private injects are not objects, so there may
+	// not be a dependency to record.
+	if dep := holder.Fields["Value"]; dep != nil {
+		o.addDep(r.Field, dep)
+		r.Object = dep
+	}
+	return holder.reflectValue.Elem().Field(0), nil
+}
+
+// lazyCandidates returns the Objects the lazy field described by the
+// Resolution may resolve to. They count as used until it is resolved.
+func lazyCandidates(o *Object, r *Resolution, unnamed, named []*Object) []*Object {
+	_, structField := o.field(r.Field)
+	tag, err := injecttag.Parse(string(structField.Tag))
+	if err != nil || tag == nil || !isLazyFunc(structField.Type) {
+		return nil
+	}
+	t := structField.Type.Out(0)
+
+	var candidates []*Object
+	switch {
+	case tag.Name != "":
+		for _, n := range named {
+			if n.Name != tag.Name {
+				continue
+			}
+			if key := namedKeyFor(n); key.module == nil || key.module == o.module {
+				candidates = append(candidates, n)
+			}
+		}
+	case tag.Qualifier != "":
+		for _, n := range named {
+			if n.Qualifier != nil && qualifierNamed(reflect.TypeOf(n.Qualifier), tag.Qualifier) &&
+				n.reflectType.AssignableTo(t) {
+				candidates = append(candidates, n)
+			}
+		}
+	case !tag.Private:
+		for _, u := range unnamed {
+			if !u.private && u.reflectType.AssignableTo(t) {
+				candidates = append(candidates, u)
+			}
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,178 @@
+package inject_test
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+)
+
+type TypeForLazySearch struct {
+	A *TypeAnswerStruct `inject:""`
+}
+
+type TypeWithLazy struct {
+	Search     func() (*TypeForLazySearch, error) `inject:",lazy"`
+	Private    func() (*TypeForLazySearch, error) `inject:"private,lazy"`
+	Named      func() (*TypeAnswerStruct, error)  `inject:"foo,lazy"`
+	Answerable func() (Answerable, error)         `inject:",lazy"`
+	A          *TypeAnswerStruct                  `inject:""`
+}
+
+func TestInjectLazy(t *testing.T) {
+	var g inject.Graph
+	var v TypeWithLazy
+	named := &TypeAnswerStruct{}
+	root := &inject.Object{Value: &v}
+	ensure.Nil(t, g.Provide(root))
+	ensure.Nil(t, g.Populate())
+
+	// The search is only created once it is needed.
+	for _, o := range g.Objects() {
+		ensure.NotDeepEqual(t, o.String(), "*inject_test.TypeForLazySearch")
+	}
+	r, err := g.Explain(root, "Search")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, r.Kind, inject.ResolvedLazy)
+	ensure.True(t, r.Object == nil)
+
+	search, err := v.Search()
+	ensure.Nil(t, err)
+	ensure.True(t, search.A == v.A)
+	again, err := v.Search()
+	ensure.Nil(t, err)
+	ensure.True(t, again == search)
+	ensure.DeepEqual(t, r.Object.Value, search)
+	ensure.True(t, root.Fields["Search"] == r.Object)
+
+	private, err := v.Private()
+	ensure.Nil(t, err)
+	ensure.True(t, private != search)
+
+	answerable, err := v.Answerable()
+	ensure.Nil(t, err)
+	ensure.True(t, answerable == v.A)
+
+	// Named objects may be provided after Populate, but before first use.
+	_, err = v.Named()
+	ensure.Err(t, err, regexp.MustCompile("did not find object named foo"))
+	ensure.Nil(t, g.Provide(&inject.Object{Value: named, Name: "foo"}))
+	actual, err := v.Named()
+	ensure.Nil(t, err)
+	ensure.True(t, actual == named)
+}
+
+func TestInjectLazyConcurrent(t *testing.T) {
+	var v TypeWithLazy
+	var g inject.Graph
+	ensure.Nil(t, g.Provide(
+		&inject.Object{Value: &v},
+		&inject.Object{Value: &TypeAnswerStruct{}, Name: "foo"},
+	))
+	ensure.Nil(t, g.Populate())
+
+	var wg sync.WaitGroup
+	results := make([]*TypeForLazySearch, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			search, err := v.Search()
+			ensure.Nil(t, err)
+			results[i] = search
+		}(i)
+	}
+	wg.Wait()
+	for _, search := range results {
+		ensure.True(t, search == results[0])
+	}
+}
+
+func TestInjectLazyInvalidType(t *testing.T) {
+	var v struct {
+		Search func() *TypeForLazySearch `inject:",lazy"`
+	}
+	err := inject.Populate(&v)
+	ensure.Err(t, err, regexp.MustCompile("^lazy inject on field Search in type .* must be of type func\\(\\) \\(T, error\\)$"))
+}
+
+func TestInjectUnknownOption(t *testing.T) {
+	var v struct {
+		A *TypeAnswerStruct `inject:",eager"`
+	}
+	err := inject.Populate(&v)
+	ensure.Err(t, err, regexp.MustCompile("^unexpected tag format `inject:\",eager\"` for field A"))
+}
+
+func TestInjectLazyCountsAsUsed(t *testing.T) {
+	g := inject.Graph{NoUnused: true}
+	var v struct {
+		Search func() (*TypeForLazySearch, error) `inject:",lazy"`
+		Named  func() (*TypeAnswerStruct, error)  `inject:"foo,lazy"`
+	}
+	ensure.Nil(t, g.Provide(
+		&inject.Object{Value: &v, Root: true},
+		&inject.Object{Value: &TypeForLazySearch{}},
+		&inject.Object{Value: &TypeAnswerStruct{}, Name: "foo"},
+		&inject.Object{Value: &TypeAnswerStruct{}, Name: "bar"},
+	))
+	err := g.Populate()
+	ensure.Err(t, err, regexp.MustCompile("^provided objects were never injected: \\*inject_test.TypeAnswerStruct named bar$"))
+}
+
+func TestInjectLazyError(t *testing.T) {
+	var v struct {
+		Named func() (*TypeAnswerStruct, error) `inject:"foo,lazy"`
+	}
+	ensure.Nil(t, inject.Populate(&v))
+	_, err := v.Named()
+	ensure.Err(t, err, regexp.MustCompile("^did not find object named foo required by field Named in type \\*struct { Named func"))
+}
+
+func TestInjectLazyConcurrentProvide(t *testing.T) {
+	var v TypeWithLazy
+	var g inject.Graph
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := v.Search()
+		ensure.Nil(t, err)
+	}()
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &TypeAnswerStruct{}, Name: "foo"}))
+	wg.Wait()
+}
+
+type TypeForLazyFailure struct {
+	A     *TypeAnswerStruct `inject:""`
+	Named *TypeAnswerStruct `inject:"foo"`
+}
+
+func TestInjectLazyFailureDoesNotGrowGraph(t *testing.T) {
+	var g inject.Graph
+	var v struct {
+		Failure func() (*TypeForLazyFailure, error) `inject:",lazy"`
+	}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+	objects := len(g.Objects())
+	for i := 0; i < 10; i++ {
+		_, err := v.Failure()
+		ensure.Err(t, err, regexp.MustCompile("did not find object named foo"))
+		ensure.DeepEqual(t, len(g.Objects()), objects)
+	}
+
+	// The created singletons were forgotten too, so they may be provided.
+	ensure.Nil(t, g.Provide(
+		&inject.Object{Value: &TypeAnswerStruct{}},
+		&inject.Object{Value: &TypeAnswerStruct{}, Name: "foo"},
+	))
+	failure, err := v.Failure()
+	ensure.Nil(t, err)
+	ensure.NotNil(t, failure.A)
+}
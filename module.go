@@ -0,0 +1,230 @@
+package inject
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// A Module is a named bundle of objects, bindings and constructors which can
+// be installed into a Graph as a unit. Modules allow sharing wiring between
+// applications.
+type Module struct {
+	Name     string
+	Requires []*Module // Installed before this Module.
+	Objects  []*Object // Copied when the Module is installed.
+	Bindings []Binding
+
+	// Exports lists the names of the named Objects visible outside the Module.
+	// Other named Objects are only visible to the Objects of the Module. If
+	// Exports is nil all names are exported.
+	Exports []string
+
+	// Constructors are functions taking no arguments and returning a single
+	// value, optionally followed by an error. They are called when the Module
+	// is installed, and the values they return are provided to the Graph.
+	Constructors []interface{}
+}
+
+// A Binding of an interface type to the implementation type unnamed injects
+// of the interface will always be assigned.
+type Binding struct {
+	Interface      reflect.Type
+	Implementation reflect.Type
+}
+
+// Install the given Modules and the Modules they require. Installing a Module
+// more than once has no effect.
+func (g *Graph) Install(modules ...*Module) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkFrozen("install modules into"); err != nil {
+		return err
+	}
+	for _, m := range modules {
+		if err := g.install(m, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Graph) install(m *Module, path []*Module) (err error) {
+	if m.Name == "" {
+		return fmt.Errorf("cannot install a module without a name")
+	}
+	for _, p := range path {
+		if p == m {
+			return fmt.Errorf("module %s requires itself", m.Name)
+		}
+	}
+	if existing, ok := g.modules[m.Name]; ok {
+		if existing != m {
+			return fmt.Errorf("installed two modules named %s", m.Name)
+		}
+		return nil
+	}
+
+ExportLoop:
+	for _, name := range m.Exports {
+		for _, o := range m.Objects {
+			if o.Name == name {
+				continue ExportLoop
+			}
+		}
+		return fmt.Errorf("module %s exports %s which it does not provide", m.Name, name)
+	}
+
+	for _, r := range m.Requires {
+		if err := g.install(r, append(path, m)); err != nil {
+			return err
+		}
+	}
+
+	// Undo what the Module added if installing it fails, so that installing it
+	// may be retried. The Modules it requires stay installed.
+	provided := make(map[*Object]bool)
+	var bound []reflect.Type
+	defer func() {
+		if err != nil {
+			g.forget(provided)
+			for _, iface := range bound {
+				delete(g.bindings, iface)
+			}
+		}
+	}()
+
+	// The Objects of the Module are copied, so that it may be installed into
+	// more than one Graph.
+	for _, o := range m.Objects {
+		o = &Object{
+			Value:     o.Value,
+			Name:      o.Name,
+			Qualifier: o.Qualifier,
+			Complete:  o.Complete,
+			Root:      o.Root,
+			module:    m,
+		}
+		if err := g.provide(o); err != nil {
+			return fmt.Errorf("module %s: %s", m.Name, err)
+		}
+		provided[o] = true
+	}
+
+	for _, b := range m.Bindings {
+		if err := g.bind(b.Interface, b.Implementation); err != nil {
+			return fmt.Errorf("module %s: %s", m.Name, err)
+		}
+		bound = append(bound, b.Interface)
+	}
+
+	for _, c := range m.Constructors {
+		// Constructors may use the Graph, so they are called without the lock.
+		g.mu.Unlock()
+		o, err := g.construct(c)
+		g.mu.Lock()
+		if err != nil {
+			return fmt.Errorf("module %s: %s", m.Name, err)
+		}
+		o.module = m
+		if err := g.provide(o); err != nil {
+			return fmt.Errorf("module %s: %s", m.Name, err)
+		}
+		provided[o] = true
+	}
+
+	if g.modules == nil {
+		g.modules = make(map[string]*Module)
+	}
+	g.modules[m.Name] = m
+	return nil
+}
+
+// construct calls the constructor and returns an Object for the value it
+// returned.
+func (g *Graph) construct(c interface{}) (*Object, error) {
+	fn := reflect.ValueOf(c)
+	t := fn.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 0 || t.NumOut() == 0 || t.NumOut() > 2 ||
+		(t.NumOut() == 2 && t.Out(1) != errorType) {
+		return nil, fmt.Errorf(
+			"expected constructor to be a func() T or func() (T, error) but got type %s",
+			t,
+		)
+	}
+
+	var span Span
+	if g.Tracer != nil {
+		span = g.Tracer.StartSpan("construct "+t.String(), nil)
+	}
+	start := time.Now()
+	out := fn.Call(nil)
+	elapsed := time.Since(start)
+	if span != nil {
+		span.End()
+	}
+
+	if len(out) == 2 && !out[1].IsNil() {
+		return nil, fmt.Errorf("constructor %s failed: %s", t, out[1].Interface())
+	}
+	return &Object{Value: out[0].Interface(), duration: elapsed}, nil
+}
+
+// exports reports if the name is visible outside the Module.
+func (m *Module) exports(name string) bool {
+	if m.Exports == nil {
+		return true
+	}
+	for _, e := range m.Exports {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// namedKey identifies a named or qualified Object. The module is nil for
+// names in the global namespace. Qualified Objects are identified by their
+// qualifier together with their type.
+type namedKey struct {
+	module    *Module
+	name      string
+	qualifier reflect.Type
+	typ       reflect.Type
+}
+
+func namedKeyFor(o *Object) namedKey {
+	if o.module != nil && !o.module.exports(o.Name) {
+		return namedKey{module: o.module, name: o.Name}
+	}
+	return namedKey{name: o.Name}
+}
+
+// lookupNamed finds the named Object, looking in the namespace of the given
+// Module before the global one.
+func (g *Graph) lookupNamed(m *Module, name string) *Object {
+	if m != nil {
+		if o := g.named[namedKey{module: m, name: name}]; o != nil {
+			return o
+		}
+	}
+	return g.named[namedKey{name: name}]
+}
+
+// providedBy describes the Module which provided the Object, if any.
+func (g *Graph) providedBy(o *Object) string {
+	if o == nil || o.module == nil {
+		return ""
+	}
+	return fmt.Sprintf(", already provided by module %s", o.module.Name)
+}
+
+// unnamedOfType returns the unnamed non private Object of the given type.
+func (g *Graph) unnamedOfType(t reflect.Type) *Object {
+	for _, o := range g.unnamed {
+		if !o.private && o.reflectType == t {
+			return o
+		}
+	}
+	return nil
+}
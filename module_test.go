@@ -0,0 +1,230 @@
+package inject_test
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+)
+
+type TypeForModuleStore struct {
+	Answer int
+}
+
+type TypeForModuleServer struct {
+	Store  *TypeForModuleStore `inject:""`
+	Answer Answerable          `inject:""`
+}
+
+var storageModule = &inject.Module{
+	Name: "storage",
+	Constructors: []interface{}{
+		func() *TypeForModuleStore { return &TypeForModuleStore{Answer: 42} },
+	},
+}
+
+var serverModule = &inject.Module{
+	Name:     "server",
+	Requires: []*inject.Module{storageModule},
+	Bindings: []inject.Binding{{
+		Interface:      reflect.TypeOf((*Answerable)(nil)).Elem(),
+		Implementation: reflect.TypeOf(&TypeAnswerStruct{}),
+	}},
+}
+
+func TestModuleInstall(t *testing.T) {
+	var g inject.Graph
+	var v TypeForModuleServer
+	ensure.Nil(t, g.Install(serverModule, storageModule, serverModule))
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+	ensure.DeepEqual(t, v.Store.Answer, 42)
+	_, ok := v.Answer.(*TypeAnswerStruct)
+	ensure.True(t, ok)
+}
+
+func TestModuleDuplicateName(t *testing.T) {
+	var g inject.Graph
+	ensure.Nil(t, g.Install(storageModule))
+	err := g.Install(&inject.Module{Name: "storage"})
+	ensure.Err(t, err, regexp.MustCompile("^installed two modules named storage$"))
+}
+
+func TestModuleCycle(t *testing.T) {
+	var g inject.Graph
+	a := &inject.Module{Name: "a"}
+	b := &inject.Module{Name: "b", Requires: []*inject.Module{a}}
+	a.Requires = []*inject.Module{b}
+	ensure.Err(t, g.Install(a), regexp.MustCompile("^module a requires itself$"))
+}
+
+func TestModuleConflictNamesModule(t *testing.T) {
+	var g inject.Graph
+	ensure.Nil(t, g.Install(&inject.Module{
+		Name:    "first",
+		Objects: []*inject.Object{{Value: &TypeForModuleStore{}, Name: "store"}},
+	}))
+	err := g.Install(&inject.Module{
+		Name:    "second",
+		Objects: []*inject.Object{{Value: &TypeForModuleStore{}, Name: "store"}},
+	})
+	ensure.Err(t, err, regexp.MustCompile(
+		"^module second: provided two instances named store, already provided by module first$"))
+
+	err = g.Install(&inject.Module{
+		Name: "third",
+		Constructors: []interface{}{
+			func() *TypeForModuleStore { return &TypeForModuleStore{} },
+			func() *TypeForModuleStore { return &TypeForModuleStore{} },
+		},
+	})
+	ensure.Err(t, err, regexp.MustCompile(
+		`^module third: provided two unnamed instances of type \*github.com/facebookgo/inject_test.TypeForModuleStore, already provided by module third$`))
+}
+
+func TestModuleConstructorError(t *testing.T) {
+	var g inject.Graph
+	err := g.Install(&inject.Module{
+		Name: "broken",
+		Constructors: []interface{}{
+			func() (*TypeForModuleStore, error) { return nil, errors.New("boom") },
+		},
+	})
+	ensure.Err(t, err, regexp.MustCompile(
+		`^module broken: constructor func\(\) \(\*inject_test.TypeForModuleStore, error\) failed: boom$`))
+}
+
+func TestModuleInvalidConstructor(t *testing.T) {
+	var g inject.Graph
+	err := g.Install(&inject.Module{
+		Name:         "broken",
+		Constructors: []interface{}{func(int) *TypeForModuleStore { return nil }},
+	})
+	ensure.Err(t, err, regexp.MustCompile(
+		`^module broken: expected constructor to be a func\(\) T or func\(\) \(T, error\) but got type func\(int\) \*inject_test.TypeForModuleStore$`))
+}
+
+type TypeForModuleLogger struct {
+	Prefix string
+}
+
+type TypeForModuleClient struct {
+	Logger *TypeForModuleLogger `inject:"logger"`
+}
+
+func TestModuleScopedNames(t *testing.T) {
+	var g inject.Graph
+	var a, b TypeForModuleClient
+	ensure.Nil(t, g.Install(
+		&inject.Module{
+			Name:    "a",
+			Exports: []string{},
+			Objects: []*inject.Object{
+				{Value: &TypeForModuleLogger{Prefix: "a"}, Name: "logger"},
+				{Value: &a, Name: "client"},
+			},
+		},
+		&inject.Module{
+			Name:    "b",
+			Exports: []string{"client"},
+			Objects: []*inject.Object{
+				{Value: &TypeForModuleLogger{Prefix: "b"}, Name: "logger"},
+				{Value: &b, Name: "client"},
+			},
+		},
+	))
+	ensure.Nil(t, g.Populate())
+	ensure.DeepEqual(t, a.Logger.Prefix, "a")
+	ensure.DeepEqual(t, b.Logger.Prefix, "b")
+}
+
+func TestModuleScopedNameFallsBackToGlobal(t *testing.T) {
+	var g inject.Graph
+	var a TypeForModuleClient
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &TypeForModuleLogger{Prefix: "global"}, Name: "logger"}))
+	ensure.Nil(t, g.Install(&inject.Module{
+		Name:    "a",
+		Exports: []string{},
+		Objects: []*inject.Object{{Value: &a}},
+	}))
+	ensure.Nil(t, g.Populate())
+	ensure.DeepEqual(t, a.Logger.Prefix, "global")
+}
+
+func TestModuleScopedNameNotVisibleOutside(t *testing.T) {
+	var g inject.Graph
+	var v TypeForModuleClient
+	ensure.Nil(t, g.Install(&inject.Module{
+		Name:    "a",
+		Exports: []string{},
+		Objects: []*inject.Object{{Value: &TypeForModuleLogger{}, Name: "logger"}},
+	}))
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Err(t, g.Populate(), regexp.MustCompile(
+		"^did not find object named logger required by field Logger in type \\*inject_test.TypeForModuleClient$"))
+}
+
+func TestModuleExportsUnknownName(t *testing.T) {
+	var g inject.Graph
+	err := g.Install(&inject.Module{Name: "a", Exports: []string{"logger"}})
+	ensure.Err(t, err, regexp.MustCompile("^module a exports logger which it does not provide$"))
+}
+
+func TestModuleInstallIntoTwoGraphs(t *testing.T) {
+	m := &inject.Module{
+		Name:    "answer",
+		Objects: []*inject.Object{{Value: &TypeAnswerStruct{}, Name: "answer"}},
+	}
+	for i := 0; i < 2; i++ {
+		var g inject.Graph
+		ensure.Nil(t, g.Install(m))
+		ensure.DeepEqual(t, len(g.Objects()), 1)
+	}
+}
+
+func TestModuleNotInstalledOnError(t *testing.T) {
+	var g inject.Graph
+	err := g.Install(&inject.Module{Name: "a", Exports: []string{"logger"}})
+	ensure.NotNil(t, err)
+	ensure.Nil(t, g.Install(&inject.Module{Name: "a"}))
+}
+
+func TestModuleRetryAfterError(t *testing.T) {
+	var g inject.Graph
+	fail := true
+	m := &inject.Module{
+		Name:    "retry",
+		Objects: []*inject.Object{{Value: &TypeForModuleStore{}, Name: "store"}},
+		Bindings: []inject.Binding{{
+			Interface:      reflect.TypeOf((*Answerable)(nil)).Elem(),
+			Implementation: reflect.TypeOf(&TypeAnswerStruct{}),
+		}},
+		Constructors: []interface{}{
+			func() (*TypeForModuleLogger, error) {
+				if fail {
+					return nil, errors.New("boom")
+				}
+				return &TypeForModuleLogger{}, nil
+			},
+		},
+	}
+	ensure.Err(t, g.Install(m), regexp.MustCompile("failed: boom$"))
+	ensure.DeepEqual(t, len(g.Objects()), 0)
+	fail = false
+	ensure.Nil(t, g.Install(m))
+	ensure.DeepEqual(t, len(g.Objects()), 2)
+}
+
+func TestModuleExportsCheckedFirst(t *testing.T) {
+	var g inject.Graph
+	err := g.Install(&inject.Module{
+		Name:    "a",
+		Objects: []*inject.Object{{Value: &TypeForModuleStore{}, Name: "store"}},
+		Exports: []string{"logger"},
+	})
+	ensure.Err(t, err, regexp.MustCompile("^module a exports logger which it does not provide$"))
+	ensure.DeepEqual(t, len(g.Objects()), 0)
+}
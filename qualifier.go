@@ -0,0 +1,36 @@
+package inject
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// lookupQualified finds the only Object whose qualifier is named by the given
+// name and which is assignable to the given type. It returns nil if there is
+// no such Object.
+func (g *Graph) lookupQualified(name string, t reflect.Type) (*Object, error) {
+	var found *Object
+	for key, o := range g.named {
+		if key.qualifier == nil || !qualifierNamed(key.qualifier, name) {
+			continue
+		}
+		if !o.reflectType.AssignableTo(t) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf(
+				"found two objects of type %s qualified by %s",
+				t,
+				name,
+			)
+		}
+		found = o
+	}
+	return found, nil
+}
+
+// qualifierNamed reports if the qualifier type has the given name, either
+// qualified by its package name or by its full import path.
+func qualifierNamed(t reflect.Type, name string) bool {
+	return name == t.String() || name == t.PkgPath()+"."+t.Name()
+}
@@ -0,0 +1,102 @@
+package inject_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+	injecttesta "github.com/facebookgo/inject/injecttesta"
+	injecttestb "github.com/facebookgo/inject/injecttestb"
+)
+
+type Primary struct{}
+
+type Secondary struct{}
+
+type TypeWithQualifiedFields struct {
+	Primary   *TypeAnswerStruct `inject:"qualifier=inject_test.Primary"`
+	Secondary Answerable        `inject:"qualifier=github.com/facebookgo/inject_test.Secondary"`
+	Plain     *TypeAnswerStruct `inject:""`
+}
+
+func TestInjectQualified(t *testing.T) {
+	var g inject.Graph
+	var v TypeWithQualifiedFields
+	primary := &TypeAnswerStruct{}
+	secondary := &TypeAnswerStruct{}
+	ensure.Nil(t, g.Provide(
+		&inject.Object{Value: &v},
+		&inject.Object{Value: primary, Qualifier: Primary{}},
+		&inject.Object{Value: secondary, Qualifier: Secondary{}},
+	))
+	ensure.Nil(t, g.Populate())
+	ensure.True(t, v.Primary == primary)
+	ensure.True(t, v.Secondary == secondary)
+	ensure.True(t, v.Plain != primary && v.Plain != secondary)
+
+	var o *inject.Object
+	for _, c := range g.Objects() {
+		if c.Value == &v {
+			o = c
+		}
+	}
+	r, err := g.Explain(o, "Primary")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, r.Kind, inject.ResolvedQualified)
+}
+
+func TestInjectQualifiedMissing(t *testing.T) {
+	var g inject.Graph
+	var v struct {
+		A *TypeAnswerStruct `inject:"qualifier=inject_test.Primary"`
+	}
+	ensure.Nil(t, g.Provide(
+		&inject.Object{Value: &v},
+		&inject.Object{Value: &TypeNestedStruct{}, Qualifier: Primary{}},
+	))
+	ensure.Err(t, g.Populate(), regexp.MustCompile(
+		`^did not find object of type \*inject_test.TypeAnswerStruct qualified by inject_test.Primary required by field A in type \*struct`))
+}
+
+func TestInjectQualifiedDuplicate(t *testing.T) {
+	var g inject.Graph
+	err := g.Provide(
+		&inject.Object{Value: &TypeAnswerStruct{}, Qualifier: Primary{}},
+		&inject.Object{Value: &TypeAnswerStruct{}, Qualifier: Primary{}},
+	)
+	ensure.Err(t, err, regexp.MustCompile(
+		`^provided two instances of type \*inject_test.TypeAnswerStruct qualified by inject_test.Primary$`))
+}
+
+func TestInjectQualifiedAndNamed(t *testing.T) {
+	var g inject.Graph
+	err := g.Provide(&inject.Object{Value: &TypeAnswerStruct{}, Name: "foo", Qualifier: Primary{}})
+	ensure.Err(t, err, regexp.MustCompile(
+		`^object \*inject_test.TypeAnswerStruct named foo qualified by inject_test.Primary cannot have both a name and a qualifier$`))
+}
+
+func TestInjectQualifierSameNameButDifferentPackage(t *testing.T) {
+	var g inject.Graph
+	var v struct {
+		A *TypeAnswerStruct `inject:"qualifier=github.com/facebookgo/inject/injecttesta.Foo"`
+		B *TypeAnswerStruct `inject:"qualifier=github.com/facebookgo/inject/injecttestb.Foo"`
+	}
+	a := &TypeAnswerStruct{}
+	b := &TypeAnswerStruct{}
+	ensure.Nil(t, g.Provide(
+		&inject.Object{Value: &v},
+		&inject.Object{Value: a, Qualifier: injecttesta.Foo{}},
+		&inject.Object{Value: b, Qualifier: injecttestb.Foo{}},
+	))
+	ensure.Nil(t, g.Populate())
+	ensure.True(t, v.A == a)
+	ensure.True(t, v.B == b)
+
+	var ambiguous struct {
+		A *TypeAnswerStruct `inject:"qualifier=a.Foo"`
+	}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &ambiguous}))
+	ensure.Err(t, g.Populate(), regexp.MustCompile(
+		`^found two objects of type \*inject_test.TypeAnswerStruct qualified by a.Foo required by field A`))
+}
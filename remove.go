@@ -0,0 +1,196 @@
+package inject
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Remove the Object from the Graph, along with the inline and private Objects
+// populated for it. It fails if the Object is still injected into a field.
+func (g *Graph) Remove(o *Object) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.remove(o, false)
+}
+
+// ForceRemove removes the Object from the Graph like Remove, but zeroes the
+// fields it is still injected into instead of failing.
+func (g *Graph) ForceRemove(o *Object) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.remove(o, true)
+}
+
+func (g *Graph) remove(o *Object, force bool) error {
+	if err := g.checkFrozen("remove objects from"); err != nil {
+		return err
+	}
+	if !g.provided(o) {
+		return fmt.Errorf("cannot remove %s which was not provided", reflect.TypeOf(o.Value))
+	}
+
+	owned := g.owned(o)
+	var refs []reference
+	for _, existing := range g.allObjects() {
+		if owned[existing] {
+			continue
+		}
+		for _, field := range sortedFieldNames(existing.Fields) {
+			if existing.Fields[field] != o {
+				continue
+			}
+			if !force {
+				return fmt.Errorf(
+					"cannot remove %s which is injected into field %s in %s",
+					o,
+					field,
+					existing,
+				)
+			}
+			refs = append(refs, reference{object: existing, field: field})
+		}
+	}
+
+	for _, ref := range refs {
+		// Lazy functions hold on to the value they resolved, so they are left
+		// alone rather than made to panic.
+		if r := ref.object.resolutions[ref.field]; r == nil || r.Kind != ResolvedLazy {
+			field, _ := ref.object.field(ref.field)
+			field.Set(reflect.Zero(field.Type()))
+		}
+		delete(ref.object.Fields, ref.field)
+		delete(ref.object.resolutions, ref.field)
+		if g.Logger != nil {
+			g.Logger.Debugf("zeroed field %s in %s", ref.field, ref.object)
+		}
+	}
+
+	g.forget(owned)
+	if g.Logger != nil {
+		g.Logger.Debugf("removed %s", o)
+	}
+	return nil
+}
+
+// Reset the Graph so it can be populated again. The injected fields of
+// incomplete Objects are zeroed, and the Objects the Graph created or
+// provided internally are removed. A frozen Graph is unfrozen.
+func (g *Graph) Reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	internal := make(map[*Object]bool)
+	for _, o := range g.allObjects() {
+		if o.created || o.private || o.embedded {
+			internal[o] = true
+		}
+		if o.Complete {
+			continue
+		}
+		for _, name := range sortedFieldNames(o.Fields) {
+			g.zero(o, name)
+		}
+		for name, r := range o.resolutions {
+			switch r.Kind {
+			case ResolvedMap, ResolvedLazy, ResolvedInline:
+				g.zero(o, name)
+			}
+		}
+		o.Fields = nil
+		o.resolutions = nil
+		o.duration = 0
+		o.populated = false
+		o.pending = false
+	}
+	g.forget(internal)
+	g.thaw()
+	g.ambiguities = nil
+	g.decorations = nil
+	g.proxied = nil
+	g.duration = 0
+}
+
+// zero the named field of the Object unless it was preset. Inline struct
+// values are left alone, their own injected fields are zeroed instead.
+func (g *Graph) zero(o *Object, name string) {
+	if r := o.resolutions[name]; r != nil && r.Kind == ResolvedPreset {
+		return
+	}
+	field, _ := o.field(name)
+	if field.Kind() == reflect.Struct {
+		return
+	}
+	field.Set(reflect.Zero(field.Type()))
+}
+
+// provided reports if the Object is in the Graph.
+func (g *Graph) provided(o *Object) bool {
+	for _, existing := range g.allObjects() {
+		if existing == o {
+			return true
+		}
+	}
+	return false
+}
+
+// owned returns the Object along with the inline and private Objects that
+// were populated for it, recursively.
+func (g *Graph) owned(o *Object) map[*Object]bool {
+	owned := map[*Object]bool{o: true}
+	queue := []*Object{o}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		var deps []*Object
+		for _, r := range current.resolutions {
+			if r.Kind == ResolvedInline && r.Object != nil {
+				deps = append(deps, r.Object)
+			}
+		}
+		for _, dep := range current.Fields {
+			if dep.private {
+				deps = append(deps, dep)
+			}
+		}
+		for _, dep := range deps {
+			if !owned[dep] {
+				owned[dep] = true
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return owned
+}
+
+// added returns the unnamed Objects added since the given index.
+func (g *Graph) added(start int) map[*Object]bool {
+	added := make(map[*Object]bool, len(g.unnamed)-start)
+	for _, o := range g.unnamed[start:] {
+		added[o] = true
+	}
+	return added
+}
+
+// forget the given Objects.
+func (g *Graph) forget(objects map[*Object]bool) {
+	unnamed := g.unnamed[:0]
+	for _, o := range g.unnamed {
+		if !objects[o] {
+			unnamed = append(unnamed, o)
+			continue
+		}
+		if !o.private {
+			delete(g.unnamedType, o.reflectType)
+		}
+	}
+	for i := len(unnamed); i < len(g.unnamed); i++ {
+		g.unnamed[i] = nil
+	}
+	g.unnamed = unnamed
+
+	for key, o := range g.named {
+		if objects[o] {
+			delete(g.named, key)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package inject_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+)
+
+func TestRemove(t *testing.T) {
+	var g inject.Graph
+	a := &inject.Object{Value: &TypeAnswerStruct{}}
+	b := &inject.Object{Value: &TypeNestedStruct{}, Name: "nested"}
+	ensure.Nil(t, g.Provide(a, b))
+	ensure.Nil(t, g.Remove(a))
+	ensure.Nil(t, g.Remove(b))
+	ensure.DeepEqual(t, len(g.Objects()), 0)
+
+	// The type can be provided again once removed.
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &TypeAnswerStruct{}}))
+}
+
+func TestRemoveReferenced(t *testing.T) {
+	var g inject.Graph
+	var v TypeNestedStruct
+	consumer := &inject.Object{Value: &v}
+	dep := &inject.Object{Value: &TypeAnswerStruct{}}
+	ensure.Nil(t, g.Provide(consumer, dep))
+	ensure.Nil(t, g.Populate())
+
+	ensure.Err(t, g.Remove(dep), regexp.MustCompile(
+		`^cannot remove \*inject_test.TypeAnswerStruct which is injected into field A in \*inject_test.TypeNestedStruct$`))
+
+	ensure.Nil(t, g.ForceRemove(dep))
+	ensure.True(t, v.A == nil)
+	ensure.DeepEqual(t, len(consumer.Fields), 0)
+}
+
+func TestRemoveOwnedObjects(t *testing.T) {
+	var g inject.Graph
+	var v struct {
+		Inline struct {
+			A *TypeAnswerStruct `inject:""`
+		} `inject:"inline"`
+		B *TypeAnswerStruct `inject:"private"`
+	}
+	o := &inject.Object{Value: &v}
+	ensure.Nil(t, g.Provide(o))
+	ensure.Nil(t, g.Populate())
+	ensure.Nil(t, g.Remove(o))
+
+	// Only the shared singleton is left.
+	objects := g.Objects()
+	ensure.DeepEqual(t, len(objects), 1)
+	ensure.True(t, objects[0].Value == v.Inline.A)
+}
+
+func TestRemoveNotProvided(t *testing.T) {
+	var g inject.Graph
+	ensure.Err(t, g.Remove(&inject.Object{Value: &TypeAnswerStruct{}}), regexp.MustCompile(
+		`^cannot remove \*inject_test.TypeAnswerStruct which was not provided$`))
+}
+
+func TestReset(t *testing.T) {
+	var g inject.Graph
+	var v struct {
+		A      *TypeAnswerStruct `inject:""`
+		B      *TypeAnswerStruct `inject:"private"`
+		M      map[string]int    `inject:"private"`
+		Preset *TypeAnswerStruct `inject:""`
+		Inline struct {
+			A *TypeAnswerStruct `inject:""`
+		} `inject:"inline"`
+	}
+	preset := &TypeAnswerStruct{}
+	v.Preset = preset
+	o := &inject.Object{Value: &v}
+	complete := &TypeNestedStruct{A: &TypeAnswerStruct{}}
+	ensure.Nil(t, g.Provide(o, &inject.Object{Value: complete, Complete: true}))
+	ensure.Nil(t, g.Populate())
+	ensure.NotNil(t, v.A)
+	first := v.A
+
+	g.Reset()
+	ensure.True(t, v.A == nil)
+	ensure.True(t, v.B == nil)
+	ensure.True(t, v.M == nil)
+	ensure.True(t, v.Inline.A == nil)
+	ensure.True(t, v.Preset == preset)
+	ensure.NotNil(t, complete.A)
+	ensure.DeepEqual(t, len(o.Fields), 0)
+	ensure.DeepEqual(t, len(g.Objects()), 2)
+
+	ensure.Nil(t, g.Populate())
+	ensure.NotNil(t, v.A)
+	ensure.True(t, v.A != first)
+	ensure.True(t, v.Inline.A == v.A)
+	ensure.NotNil(t, v.B)
+}
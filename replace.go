@@ -0,0 +1,207 @@
+package inject
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/facebookgo/inject/internal/injecttag"
+)
+
+// Starter is implemented by Objects that need to be started when they are
+// swapped into a Graph by Replace.
+type Starter interface {
+	Start() error
+}
+
+// Stopper is implemented by Objects that need to be stopped when they are
+// swapped out of a Graph by Replace.
+type Stopper interface {
+	Stop() error
+}
+
+// reference is a field referring to an Object.
+type reference struct {
+	object *Object
+	field  string
+}
+
+// Replace the old Object with the new one in a populated Graph. The new Object
+// takes the place of the old one, including its name, and every field the old
+// Object was injected into is assigned the new one. The new Object is
+// populated unless it is complete, and started if it is a Starter before any
+// field is assigned. The old Object is stopped afterwards if it is a Stopper,
+// in which case an error stopping it is returned with the replacement done.
+// The Graph is not locked while starting and stopping, so they may use it.
+func (g *Graph) Replace(old, new *Object) error {
+	g.mu.Lock()
+	refs, created, err := g.swap(old, new)
+	g.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if s, ok := new.Value.(Starter); ok {
+		start := func(*Object) error { return s.Start() }
+		if err := g.timed("start", new, start); err != nil {
+			g.mu.Lock()
+			g.substitute(new, old)
+			g.forget(created)
+			g.mu.Unlock()
+			return fmt.Errorf("failed to start %s: %s", new, err)
+		}
+	}
+
+	g.mu.Lock()
+	for _, ref := range refs {
+		// The field may have been reassigned while the new Object was starting.
+		if ref.object.Fields[ref.field] == old {
+			g.reassign(ref, new)
+		}
+	}
+	g.mu.Unlock()
+
+	if s, ok := old.Value.(Stopper); ok {
+		if err := s.Stop(); err != nil {
+			return fmt.Errorf("failed to stop %s: %s", old, err)
+		}
+	}
+	return nil
+}
+
+// swap puts the new Object in the place of the old one and populates it. It
+// returns the fields the old Object was injected into, along with the Objects
+// created while populating the new one.
+func (g *Graph) swap(old, new *Object) ([]reference, map[*Object]bool, error) {
+	if err := g.checkFrozen("replace objects in"); err != nil {
+		return nil, nil, err
+	}
+
+	index := -1
+	for i, o := range g.unnamed {
+		if o == old {
+			index = i
+		}
+		if o == new {
+			return nil, nil, fmt.Errorf("cannot replace %s with %s which was already provided", old, new)
+		}
+	}
+	var key namedKey
+	var named bool
+	for k, o := range g.named {
+		if o == old {
+			key, named = k, true
+		}
+		if o == new {
+			return nil, nil, fmt.Errorf("cannot replace %s with %s which was already provided", old, new)
+		}
+	}
+	if index == -1 && !named {
+		return nil, nil, fmt.Errorf("cannot replace %s which was not provided", reflect.TypeOf(old.Value))
+	}
+	put := func(o *Object) {
+		if index != -1 {
+			g.unnamed[index] = o
+		} else {
+			g.named[key] = o
+		}
+	}
+
+	new.Name = old.Name
+	new.Qualifier = old.Qualifier
+	new.module = old.module
+	new.private = old.private
+	new.seq = old.seq
+	new.reflectType = reflect.TypeOf(new.Value)
+	new.reflectValue = reflect.ValueOf(new.Value)
+
+	// Unnamed and qualified Objects are identified by their type.
+	if old.Name == "" && new.reflectType != old.reflectType {
+		return nil, nil, fmt.Errorf("cannot replace %s with %s of a different type", old, new)
+	}
+
+	var refs []reference
+	for _, o := range g.allObjects() {
+		for _, field := range sortedFieldNames(o.Fields) {
+			if o.Fields[field] != old {
+				continue
+			}
+			if r := o.resolutions[field]; r != nil && r.Kind == ResolvedLazy {
+				return nil, nil, fmt.Errorf(
+					"cannot replace %s injected lazily into field %s in %s",
+					old,
+					field,
+					o,
+				)
+			}
+			fieldType := o.reflectValue.Elem().FieldByName(field).Type()
+			if !new.reflectType.AssignableTo(fieldType) {
+				return nil, nil, fmt.Errorf(
+					"cannot replace %s with %s which is not assignable to field %s in %s",
+					old,
+					new,
+					field,
+					o,
+				)
+			}
+			refs = append(refs, reference{object: o, field: field})
+		}
+	}
+
+	start := len(g.unnamed)
+	put(new)
+	if err := g.populateReplacement(new); err != nil {
+		put(old)
+		g.forget(g.added(start))
+		return nil, nil, err
+	}
+	return refs, g.added(start), nil
+}
+
+// substitute puts the new Object in the place of the old one, which may have
+// moved since it was provided.
+func (g *Graph) substitute(old, new *Object) {
+	for i, o := range g.unnamed {
+		if o == old {
+			g.unnamed[i] = new
+		}
+	}
+	for k, o := range g.named {
+		if o == old {
+			g.named[k] = new
+		}
+	}
+}
+
+// populateReplacement populates the Object replacing another one, along with
+// the Objects created for it.
+func (g *Graph) populateReplacement(o *Object) error {
+	if o.Complete {
+		return nil
+	}
+	start := len(g.unnamed)
+	if err := g.timed("populate", o, g.populateExplicit); err != nil {
+		return err
+	}
+	if err := g.populateUnnamed(start); err != nil {
+		return err
+	}
+	return g.timed("populate interfaces", o, g.populateUnnamedInterface)
+}
+
+// reassign the field to the new Object.
+func (g *Graph) reassign(ref reference, new *Object) {
+	o := ref.object
+	field, structField := o.field(ref.field)
+
+	// The tag was valid when the field was first assigned.
+	tag, _ := injecttag.Parse(string(structField.Tag))
+	field.Set(g.fieldValue(new, field.Type(), tag))
+	if g.Logger != nil {
+		g.Logger.Debugf("replaced %s in field %s in %s", new, ref.field, o)
+	}
+	o.Fields[ref.field] = new
+	if r := o.resolutions[ref.field]; r != nil {
+		r.Object = new
+	}
+	g.emit(Event{Kind: EventAssigned, Object: o, Field: ref.field, Dep: new})
+}
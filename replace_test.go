@@ -0,0 +1,141 @@
+package inject_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+)
+
+type TypeForReplaceClient struct {
+	Config   *TypeAnswerStruct `inject:""`
+	started  bool
+	stopped  bool
+	startErr error
+}
+
+func (c *TypeForReplaceClient) Start() error {
+	c.started = true
+	return c.startErr
+}
+
+func (c *TypeForReplaceClient) Stop() error {
+	c.stopped = true
+	return nil
+}
+
+type TypeForReplaceConsumer struct {
+	Client *TypeForReplaceClient `inject:""`
+}
+
+func TestReplace(t *testing.T) {
+	var g inject.Graph
+	var a TypeForReplaceConsumer
+	var b struct {
+		Client *TypeForReplaceClient `inject:""`
+	}
+	old := &inject.Object{Value: &TypeForReplaceClient{}}
+	consumer := &inject.Object{Value: &a}
+	ensure.Nil(t, g.Provide(consumer, &inject.Object{Value: &b}, old))
+	ensure.Nil(t, g.Populate())
+
+	client := &TypeForReplaceClient{}
+	replacement := &inject.Object{Value: client}
+	ensure.Nil(t, g.Replace(old, replacement))
+	ensure.True(t, a.Client == client)
+	ensure.True(t, b.Client == client)
+	ensure.True(t, consumer.Fields["Client"] == replacement)
+	ensure.NotNil(t, client.Config)
+	ensure.True(t, client.started)
+	ensure.True(t, old.Value.(*TypeForReplaceClient).stopped)
+
+	r, err := g.Explain(consumer, "Client")
+	ensure.Nil(t, err)
+	ensure.True(t, r.Object == replacement)
+}
+
+func TestReplaceNamed(t *testing.T) {
+	var g inject.Graph
+	var v struct {
+		Answer Answerable `inject:"answer"`
+	}
+	old := &inject.Object{Value: &TypeAnswerStruct{}, Name: "answer"}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}, old))
+	ensure.Nil(t, g.Populate())
+
+	nested := &TypeNestedStruct{A: &TypeAnswerStruct{}}
+	replacement := &inject.Object{Value: nested, Complete: true}
+	ensure.Nil(t, g.Replace(old, replacement))
+	ensure.True(t, v.Answer == nested)
+	ensure.DeepEqual(t, replacement.Name, "answer")
+}
+
+func TestReplaceStartError(t *testing.T) {
+	var g inject.Graph
+	var v TypeForReplaceConsumer
+	client := &TypeForReplaceClient{}
+	// The old client is complete, so the config is only created for the new one.
+	old := &inject.Object{Value: client, Complete: true}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}, old))
+	ensure.Nil(t, g.Populate())
+	objects := g.SortedObjects()
+
+	err := g.Replace(old, &inject.Object{
+		Value: &TypeForReplaceClient{startErr: errors.New("boom")},
+	})
+	ensure.Err(t, err, regexp.MustCompile(
+		`^failed to start \*inject_test.TypeForReplaceClient: boom$`))
+	ensure.True(t, v.Client == client)
+	ensure.False(t, client.stopped)
+	ensure.DeepEqual(t, g.SortedObjects(), objects)
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &TypeAnswerStruct{}}))
+}
+
+func TestReplaceNotProvided(t *testing.T) {
+	var g inject.Graph
+	err := g.Replace(
+		&inject.Object{Value: &TypeAnswerStruct{}},
+		&inject.Object{Value: &TypeAnswerStruct{}},
+	)
+	ensure.Err(t, err, regexp.MustCompile(
+		`^cannot replace \*inject_test.TypeAnswerStruct which was not provided$`))
+}
+
+func TestReplaceDifferentType(t *testing.T) {
+	var g inject.Graph
+	old := &inject.Object{Value: &TypeAnswerStruct{}}
+	ensure.Nil(t, g.Provide(old))
+	err := g.Replace(old, &inject.Object{Value: &TypeNestedStruct{}})
+	ensure.Err(t, err, regexp.MustCompile(
+		`^cannot replace \*inject_test.TypeAnswerStruct with \*inject_test.TypeNestedStruct of a different type$`))
+}
+
+type TypeForReplaceLazyStarter struct {
+	Answer func() (*TypeAnswerStruct, error) `inject:",lazy"`
+	answer *TypeAnswerStruct
+}
+
+func (s *TypeForReplaceLazyStarter) Start() error {
+	var err error
+	s.answer, err = s.Answer()
+	return err
+}
+
+func TestReplaceStarterUsesLazyField(t *testing.T) {
+	var tracer testTracer
+	g := inject.Graph{Tracer: &tracer}
+	var v struct {
+		S *TypeForReplaceLazyStarter `inject:""`
+	}
+	old := &inject.Object{Value: &TypeForReplaceLazyStarter{}}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}, old))
+	ensure.Nil(t, g.Populate())
+
+	replacement := &TypeForReplaceLazyStarter{}
+	ensure.Nil(t, g.Replace(old, &inject.Object{Value: replacement}))
+	ensure.True(t, v.S == replacement)
+	ensure.NotNil(t, replacement.answer)
+	ensure.DeepEqual(t, tracer.ended[len(tracer.ended)-1], "start *inject_test.TypeForReplaceLazyStarter")
+}
@@ -0,0 +1,92 @@
+package inject
+
+import "sort"
+
+// An Ambiguity is an interface field resolved by an earlier Populate which
+// later Objects are also assignable to. Had they been provided from the start,
+// Populate would have failed.
+type Ambiguity struct {
+	Object     *Object
+	Field      string
+	Resolution *Resolution
+	Candidates []*Object // The later Objects assignable to the field.
+}
+
+// Ambiguous returns the interface fields that became ambiguous as Objects were
+// provided after an earlier Populate. It is only meaningful after Populate.
+func (g *Graph) Ambiguous() []*Ambiguity {
+	return g.ambiguities
+}
+
+// settled reports if Populate has nothing left to do for the Object.
+func (o *Object) settled() bool {
+	return o.Complete || (o.populated && !o.pending)
+}
+
+// skipOptional leaves an optional field alone for a later Populate to fill.
+func (g *Graph) skipOptional(o *Object, field string) {
+	o.pending = true
+	if g.Logger != nil {
+		g.Logger.Debugf("left optional field %s in %s missing", field, o)
+	}
+}
+
+// findAmbiguous records the interface fields of the previously populated
+// Objects that Objects added since are also assignable to.
+func (g *Graph) findAmbiguous(populated map[*Object]bool) {
+	if len(populated) == 0 {
+		return
+	}
+	for _, o := range g.allObjects() {
+		if !populated[o] {
+			continue
+		}
+		for _, name := range sortedResolutionNames(o.resolutions) {
+			r := o.resolutions[name]
+			if r.Kind != ResolvedInterface {
+				continue
+			}
+			field, _ := o.field(name)
+			fieldType := field.Type()
+			var candidates []*Object
+			for _, existing := range g.unnamed {
+				if populated[existing] || existing.private || existing == r.Object {
+					continue
+				}
+				if !existing.reflectType.AssignableTo(fieldType) {
+					continue
+				}
+				if embedsInjected(existing.reflectType, fieldType) {
+					continue
+				}
+				candidates = append(candidates, existing)
+			}
+			if len(candidates) == 0 {
+				continue
+			}
+			if g.Logger != nil {
+				g.Logger.Debugf(
+					"interface field %s in %s became ambiguous with %s",
+					name,
+					o,
+					candidates[0],
+				)
+			}
+			g.ambiguities = append(g.ambiguities, &Ambiguity{
+				Object:     o,
+				Field:      name,
+				Resolution: r,
+				Candidates: candidates,
+			})
+		}
+	}
+}
+
+func sortedResolutionNames(resolutions map[string]*Resolution) []string {
+	names := make([]string, 0, len(resolutions))
+	for name := range resolutions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
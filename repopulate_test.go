@@ -0,0 +1,96 @@
+package inject_test
+
+import (
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+)
+
+func TestPopulateAgain(t *testing.T) {
+	var tracer testTracer
+	g := inject.Graph{Tracer: &tracer}
+	var first TypeNestedStruct
+	firstObject := &inject.Object{Value: &first}
+	ensure.Nil(t, g.Provide(firstObject))
+	ensure.Nil(t, g.Populate())
+
+	tracer.ended = nil
+	var plugin struct {
+		A      *TypeAnswerStruct `inject:""`
+		Nested *TypeNestedStruct `inject:""`
+	}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &plugin}))
+	ensure.Nil(t, g.Populate())
+	ensure.True(t, plugin.A == first.A)
+	ensure.True(t, plugin.Nested == &first)
+
+	// The earlier object was left alone.
+	for _, name := range tracer.ended {
+		ensure.NotDeepEqual(t, name, "populate *inject_test.TypeNestedStruct")
+	}
+	r, err := g.Explain(firstObject, "A")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, r.Kind, inject.ResolvedCreated)
+	ensure.DeepEqual(t, len(g.Ambiguous()), 0)
+}
+
+func TestPopulateOptional(t *testing.T) {
+	var g inject.Graph
+	var v struct {
+		Named      *TypeAnswerStruct `inject:"answer,optional"`
+		Answerable Answerable        `inject:",optional"`
+		Func       func()            `inject:",optional"`
+	}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+	ensure.True(t, v.Named == nil)
+	ensure.True(t, v.Answerable == nil)
+	ensure.True(t, v.Func == nil)
+
+	named := &TypeAnswerStruct{}
+	unnamed := &TypeAnswerStruct{}
+	f := func() {}
+	ensure.Nil(t, g.Provide(
+		&inject.Object{Value: named, Name: "answer"},
+		&inject.Object{Value: unnamed},
+		&inject.Object{Value: f},
+	))
+	ensure.Nil(t, g.Populate())
+	ensure.True(t, v.Named == named)
+	ensure.True(t, v.Answerable == unnamed)
+	ensure.NotNil(t, v.Func)
+}
+
+func TestPopulateOptionalStrict(t *testing.T) {
+	g := inject.Graph{Strict: true}
+	var v struct {
+		A *TypeAnswerStruct `inject:",optional"`
+	}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+	ensure.True(t, v.A == nil)
+}
+
+func TestPopulateAgainAmbiguous(t *testing.T) {
+	var g inject.Graph
+	var v struct {
+		Answerable Answerable `inject:""`
+	}
+	o := &inject.Object{Value: &v}
+	answer := &inject.Object{Value: &TypeAnswerStruct{}}
+	ensure.Nil(t, g.Provide(o, answer))
+	ensure.Nil(t, g.Populate())
+
+	late := &inject.Object{Value: &TypeNestedStruct{}}
+	ensure.Nil(t, g.Provide(late))
+	ensure.Nil(t, g.Populate())
+	ensure.True(t, v.Answerable == answer.Value)
+
+	ambiguous := g.Ambiguous()
+	ensure.DeepEqual(t, len(ambiguous), 1)
+	ensure.True(t, ambiguous[0].Object == o)
+	ensure.DeepEqual(t, ambiguous[0].Field, "Answerable")
+	ensure.True(t, ambiguous[0].Resolution.Object == answer)
+	ensure.DeepEqual(t, ambiguous[0].Candidates, []*inject.Object{late})
+}
@@ -0,0 +1,127 @@
+package inject
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// A Span is started by a Tracer and ended once the traced work is done.
+type Span interface {
+	End()
+}
+
+// A Tracer allows for emitting spans as inject populates the object graph. The
+// Object will be nil for spans covering the entire Graph.
+type Tracer interface {
+	StartSpan(name string, o *Object) Span
+}
+
+// Timing of a single Object.
+type Timing struct {
+	Object   *Object
+	Duration time.Duration // Time spent populating the Object itself.
+}
+
+// TimingReport describes where time was spent populating a Graph.
+type TimingReport struct {
+	Total        time.Duration // Time spent in Populate.
+	Objects      []*Timing     // Sorted slowest first.
+	CriticalPath []*Timing     // The slowest chain of dependencies, starting at the dependent.
+}
+
+// String representation suitable for human consumption.
+func (r *TimingReport) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "total %s\n", r.Total)
+	for _, t := range r.Objects {
+		fmt.Fprintf(&buf, "%12s %s\n", t.Duration, t.Object)
+	}
+	buf.WriteString("critical path:\n")
+	for _, t := range r.CriticalPath {
+		fmt.Fprintf(&buf, "%12s %s\n", t.Duration, t.Object)
+	}
+	return buf.String()
+}
+
+// Timings returns a report of the time spent populating the Graph. It is only
+// meaningful after Populate.
+func (g *Graph) Timings() *TimingReport {
+	r := &TimingReport{Total: g.duration}
+	timings := make(map[*Object]*Timing)
+	unnamed, named := g.view()
+	for _, o := range append(append([]*Object(nil), unnamed...), named...) {
+		t := &Timing{Object: o, Duration: o.duration}
+		timings[o] = t
+		r.Objects = append(r.Objects, t)
+	}
+	sort.SliceStable(r.Objects, func(i, j int) bool {
+		return r.Objects[i].Duration > r.Objects[j].Duration
+	})
+
+	// The critical path is the slowest path following the dependency edges.
+	// The graph may contain cycles, which we break by ignoring edges back to
+	// an object that is still being visited.
+	total := make(map[*Object]time.Duration)
+	next := make(map[*Object]*Object)
+	visiting := make(map[*Object]bool)
+	var visit func(o *Object) time.Duration
+	visit = func(o *Object) time.Duration {
+		if d, ok := total[o]; ok {
+			return d
+		}
+		visiting[o] = true
+		var slowest time.Duration
+		fields := g.edges(o).fields
+		for _, name := range sortedFieldNames(fields) {
+			dep := fields[name]
+			if visiting[dep] {
+				continue
+			}
+			if d := visit(dep); next[o] == nil || d > slowest {
+				slowest = d
+				next[o] = dep
+			}
+		}
+		visiting[o] = false
+		total[o] = o.duration + slowest
+		return total[o]
+	}
+
+	var start *Object
+	for _, t := range r.Objects {
+		if d := visit(t.Object); start == nil || d > total[start] {
+			start = t.Object
+		}
+	}
+	for o := start; o != nil; o = next[o] {
+		r.CriticalPath = append(r.CriticalPath, timings[o])
+	}
+	return r
+}
+
+// timed runs the given populate step for the Object, accounting the time
+// spent to the Object and emitting a span if necessary.
+func (g *Graph) timed(name string, o *Object, step func(*Object) error) error {
+	var span Span
+	if g.Tracer != nil {
+		span = g.Tracer.StartSpan(name, o)
+	}
+	start := time.Now()
+	err := step(o)
+	o.duration += time.Since(start)
+	if span != nil {
+		span.End()
+	}
+	return err
+}
+
+func sortedFieldNames(fields map[string]*Object) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,77 @@
+package inject_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/inject"
+)
+
+type testSpan struct {
+	tracer *testTracer
+	name   string
+}
+
+func (s *testSpan) End() {
+	s.tracer.ended = append(s.tracer.ended, s.name)
+}
+
+type testTracer struct {
+	ended []string
+}
+
+func (t *testTracer) StartSpan(name string, o *inject.Object) inject.Span {
+	if o != nil {
+		name = fmt.Sprintf("%s %s", name, o)
+	}
+	return &testSpan{tracer: t, name: name}
+}
+
+func TestTimings(t *testing.T) {
+	var tracer testTracer
+	g := inject.Graph{Tracer: &tracer}
+	var v struct {
+		B *TypeNestedStruct `inject:""`
+	}
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+
+	ensure.DeepEqual(t, tracer.ended[len(tracer.ended)-1], "populate graph")
+	ensure.StringContains(t, strings.Join(tracer.ended, "\n"), "populate *inject_test.TypeNestedStruct")
+
+	r := g.Timings()
+	ensure.True(t, r.Total > 0)
+	ensure.DeepEqual(t, len(r.Objects), 3)
+	for i := 1; i < len(r.Objects); i++ {
+		ensure.True(t, r.Objects[i-1].Duration >= r.Objects[i].Duration)
+	}
+	ensure.True(t, len(r.CriticalPath) > 0)
+	for i := 1; i < len(r.CriticalPath); i++ {
+		var found bool
+		for _, dep := range r.CriticalPath[i-1].Object.Fields {
+			if dep == r.CriticalPath[i].Object {
+				found = true
+			}
+		}
+		ensure.True(t, found, r.CriticalPath)
+	}
+	ensure.StringContains(t, r.String(), "critical path:")
+}
+
+func TestTimingsWithCycle(t *testing.T) {
+	var g inject.Graph
+	var v TypeForCycleA
+	ensure.Nil(t, g.Provide(&inject.Object{Value: &v}))
+	ensure.Nil(t, g.Populate())
+	ensure.DeepEqual(t, len(g.Timings().Objects), 2)
+}
+
+type TypeForCycleA struct {
+	B *TypeForCycleB `inject:""`
+}
+
+type TypeForCycleB struct {
+	A *TypeForCycleA `inject:""`
+}